@@ -17,18 +17,23 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"syscall"
+	"time"
 
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
 	"github.com/Project-HAMi/ascend-device-plugin/internal"
 	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
 	"github.com/Project-HAMi/ascend-device-plugin/internal/monitor"
 	"github.com/Project-HAMi/ascend-device-plugin/internal/server"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/simulated"
 	"github.com/Project-HAMi/ascend-device-plugin/version"
-	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
 	"huawei.com/npu-exporter/utils/logger"
 	"k8s.io/klog/v2"
 	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
@@ -40,8 +45,31 @@ var (
 	nodeConfigFile        = flag.String("node_config_file", "", "node specific config file path")
 	nodeName              = flag.String("node_name", os.Getenv("NODE_NAME"), "node name")
 	checkIdleVNPUInterval = flag.Int("check_idle_vnpu_interval", 60, "the interval (in seconds) to check idle vNPU and release them")
+	metricsBindAddress    = flag.String("metrics_bind_address", ":9395", "address the metrics server listens on")
+	vnpuContainersPath    = flag.String("vnpu_containers_path", "/usr/local/hami-vnpu-core/containers", "host dir holding the per-container hami-vnpu-core shmem dirs")
+	metricsLegacyGPUNames = flag.Bool("metrics_legacy_gpu_names", true, "also export NPU metrics under the legacy hami_host_gpu_*/hami_vgpu_* names used by HAMi GPU dashboards")
+	metricsTLSCertFile    = flag.String("metrics_tls_cert_file", "", "TLS certificate file of the metrics server, enables HTTPS together with --metrics_tls_key_file")
+	metricsTLSKeyFile     = flag.String("metrics_tls_key_file", "", "TLS key file of the metrics server")
+	metricsClientCAFile   = flag.String("metrics_client_ca_file", "", "CA file to verify metrics client certificates against; requires client certs when set")
+	debugBindAddress      = flag.String("debug_bind_address", "127.0.0.1:9396", "address the read-only debug API listens on, empty to disable")
+	healthBindAddress     = flag.String("health_bind_address", ":9397", "address the plain-HTTP /healthz and /readyz probes listen on, empty to serve them from the metrics server")
+	cdiEnabled            = flag.Bool("cdi_enabled", false, "write CDI specs for the NPUs and return CDI devices from Allocate instead of relying on the Ascend runtime")
+	cdiSpecDir            = flag.String("cdi_spec_dir", "/var/run/cdi", "dir the CDI specs are written to, must be one the container runtime reads")
+	runtimeLess           = flag.Bool("runtime_less", false, "return the NPU device nodes and driver mounts from Allocate so pods need no ascend RuntimeClass; --cdi_enabled takes precedence")
+	backend               = flag.String("backend", backendDCMI, "NPU backend, dcmi for Ascend hardware or simulated for the NPUs described by --simulated_config")
+	simulatedConfig       = flag.String("simulated_config", "", "YAML file describing the simulated NPUs, required with --backend=simulated")
 )
 
+// NPU backends selectable with --backend.
+const (
+	backendDCMI      = "dcmi"
+	backendSimulated = "simulated"
+)
+
+// metricsShutdownTimeout bounds how long in-flight scrapes and debug requests
+// may delay exit.
+const metricsShutdownTimeout = 5 * time.Second
+
 func checkFlags() {
 	version.CheckVersionFlag()
 	if *configFile == "" {
@@ -50,73 +78,73 @@ func checkFlags() {
 	if *nodeName == "" {
 		klog.Fatalf("node name not set, use --node_name or env NODE_NAME to set node name")
 	}
+	switch *backend {
+	case backendDCMI:
+	case backendSimulated:
+		if *simulatedConfig == "" {
+			klog.Fatalf("simulated config not set, use --simulated_config to set the file describing the simulated NPUs")
+		}
+	default:
+		klog.Fatalf("unknown backend %q, use %s or %s", *backend, backendDCMI, backendSimulated)
+	}
 }
 
-func start(ps *server.PluginServer) error {
-	klog.Info("Starting FS watcher.")
-	watcher, err := internal.NewFSWatcher(v1beta1.DevicePluginPath)
+// newAscendManagers returns the managers of the NPUs of the selected backend.
+func newAscendManagers() ([]*manager.AscendManager, error) {
+	if *backend != backendSimulated {
+		return manager.NewAscendManagers()
+	}
+	sim, err := simulated.Load(*simulatedConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create FS watcher: %v", err)
+		return nil, fmt.Errorf("load simulated config: %w", err)
 	}
-	defer func(watcher *fsnotify.Watcher) {
-		_ = watcher.Close()
-	}(watcher)
+	klog.Warningf("using simulated NPUs from %s, no Ascend hardware is used", *simulatedConfig)
+	return manager.NewAscendManagersFor(sim)
+}
 
-	klog.Info("Starting OS watcher.")
-	sigs := internal.NewOSWatcher(syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+// configDirs returns the directories holding the config files.
+func configDirs() []string {
+	var dirs []string
+	for _, f := range []string{*configFile, *nodeConfigFile} {
+		if f == "" {
+			continue
+		}
+		dir := filepath.Dir(f)
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
 
-	var restarting bool
-	//var restartTimeout <-chan time.Time
-restart:
-	if restarting {
-		err := ps.Stop()
+// reloadConfig reloads the config of every manager and reports whether any of
+// them needs the plugins to re-register with kubelet and HAMi. Otherwise it
+// rewrites the CDI specs of the servers, which the restart would have done.
+func reloadConfig(mgrs []*manager.AscendManager, servers []*server.PluginServer) bool {
+	reregister := false
+	for _, mgr := range mgrs {
+		changed, err := mgr.ReloadConfig(*configFile, *nodeConfigFile, *nodeName)
 		if err != nil {
-			klog.Errorf("Failed to stop plugin server: %v", err)
+			klog.Errorf("reload config for chip %s failed, keeping the current config: %v", mgr.ChipName(), err)
+			continue
 		}
+		reregister = reregister || changed
 	}
-	restarting = true
-	if err := ps.CleanupIdleVNPUs(); err != nil {
-		klog.Errorf("Failed to cleanup idle vNPUs: %v", err)
+	if reregister {
+		return true
 	}
-	klog.Info("Starting Plugins.")
-	err = ps.Start()
-	if err != nil {
-		klog.Errorf("Failed to start plugin server: %v", err)
-		return err
-	}
-
-	for {
-		select {
-		//case <-restartTimeout:
-		//	goto restart
-		case event := <-watcher.Events:
-			if event.Name == v1beta1.KubeletSocket && event.Op&fsnotify.Create == fsnotify.Create {
-				klog.Infof("inotify: %s created, restarting.", v1beta1.KubeletSocket)
-				goto restart
-			}
-		case err := <-watcher.Errors:
-			klog.Errorf("inotify: %s", err)
-		case s := <-sigs:
-			switch s {
-			case syscall.SIGHUP:
-				klog.Info("Received SIGHUP, restarting.")
-				goto restart
-			default:
-				klog.Infof("Received signal \"%v\", shutting down.", s)
-				goto exit
-			}
+	for _, ps := range servers {
+		if err := ps.RefreshCDISpec(); err != nil {
+			klog.Errorf("refresh CDI spec after config reload: %v", err)
 		}
 	}
-exit:
-	err = ps.Stop()
-	if err != nil {
-		klog.Errorf("Failed to stop plugin server: %v", err)
-		return err
-	}
-	return nil
+	return false
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == validateConfigCmd {
+		os.Exit(runValidateConfig(os.Args[2:], os.Stdout))
+	}
 	klog.InitFlags(nil)
 	flag.Parse()
 	checkFlags()
@@ -130,40 +158,137 @@ func main() {
 	if err != nil {
 		klog.Fatalf("init huawei run logger failed, %v", err)
 	}
-	mgr, err := manager.NewAscendManager()
+	mgrs, err := newAscendManagers()
 	if err != nil {
 		klog.Fatalf("init AscendManager failed, error is %v", err)
 	}
-	err = mgr.LoadConfig(*configFile)
-	if err != nil {
-		klog.Fatalf("load config failed, error is %v", err)
-	}
-	if *nodeConfigFile != "" {
-		err = mgr.LoadNodeConfig(*nodeConfigFile, *nodeName)
+	var (
+		servers      []*server.PluginServer
+		configured   []*manager.AscendManager
+		hamiVnpuCore bool
+	)
+	resourceNames := map[string]string{}
+	for _, mgr := range mgrs {
+		err = mgr.LoadConfig(*configFile)
+		if err != nil {
+			if len(mgrs) == 1 {
+				klog.Fatalf("load config failed, error is %v", err)
+			}
+			klog.Errorf("load config for chip %s failed, skipping its devices: %v", mgr.ChipName(), err)
+			continue
+		}
+		if chip, ok := resourceNames[mgr.ResourceName()]; ok {
+			klog.Fatalf("chips %s and %s share resource name %s", chip, mgr.ChipName(), mgr.ResourceName())
+		}
+		resourceNames[mgr.ResourceName()] = mgr.ChipName()
+		if *nodeConfigFile != "" {
+			err = mgr.LoadNodeConfig(*nodeConfigFile, *nodeName)
+			if err != nil {
+				klog.Errorf("load node config failed: %v", err)
+			}
+		}
+		ps, err := server.NewPluginServer(mgr, *nodeName, *checkIdleVNPUInterval)
 		if err != nil {
-			klog.Errorf("load node config failed: %v", err)
+			klog.Fatalf("init PluginServer failed, error is %v", err)
 		}
+		if *cdiEnabled {
+			ps.SetCDISpecDir(*cdiSpecDir)
+		}
+		ps.SetRuntimeLess(*runtimeLess)
+		klog.Infof("serving chip %s as %s", mgr.ChipName(), mgr.ResourceName())
+		servers = append(servers, ps)
+		configured = append(configured, mgr)
+		hamiVnpuCore = hamiVnpuCore || mgr.IsHamiVnpuCore()
 	}
-	server, err := server.NewPluginServer(mgr, *nodeName, *checkIdleVNPUInterval)
-	if err != nil {
-		klog.Fatalf("init PluginServer failed, error is %v", err)
+	if len(servers) == 0 {
+		klog.Fatalf("no chip on node %s has a vnpu config", *nodeName)
 	}
 	client.InitGlobalClient()
+	recorder := server.NewEventRecorder(client.GetClient(), *nodeName)
+	for _, ps := range servers {
+		ps.SetEventRecorder(recorder)
+	}
 
-	if mgr.IsHamiVnpuCore() {
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					klog.Errorf("recovered from panic in vNPU metrics server: %v", r)
-				}
-			}()
-			monitor.StartMetricsServer(":9395", "/usr/local/hami-vnpu-core/containers")
-		}()
+	// hami-vnpu-core containers are only read when soft slicing is enabled;
+	// device faults are exported on every node.
+	containersPath := ""
+	if hamiVnpuCore {
+		containersPath = *vnpuContainersPath
+	} else {
+		klog.Info("hami-vnpu-core disabled on this node; not collecting vNPU container metrics")
+	}
+	podResources := manager.NewPodResourcesClient(manager.PodResourcesSocket)
+	faultMgrs := make([]manager.Manager, 0, len(configured))
+	for _, mgr := range configured {
+		mgr.SetPodResourcesLister(podResources)
+		faultMgrs = append(faultMgrs, mgr)
+	}
+	collectors := append([]prometheus.Collector{monitor.NewFaultCollector(faultMgrs)}, server.MetricsCollectors()...)
+	// Simulated NPUs have no DCMI to read host telemetry from.
+	metricsServer, err := monitor.NewMetricsServer(monitor.MetricsServerConfig{
+		BindAddr:             *metricsBindAddress,
+		ContainersPath:       containersPath,
+		DisableHostTelemetry: *backend == backendSimulated,
+		LegacyGPUNames:       *metricsLegacyGPUNames,
+		TLSCertFile:          *metricsTLSCertFile,
+		TLSKeyFile:           *metricsTLSKeyFile,
+		ClientCAFile:         *metricsClientCAFile,
+	}, podResources, collectors...)
+	if err != nil {
+		klog.Fatalf("init metrics server failed, error is %v", err)
+	}
+	// The probes get their own plain-HTTP listener so that kubelet's httpGet
+	// probes keep working when the metrics server is TLS-only.
+	var probeServer *server.ProbeServer
+	if *healthBindAddress != "" {
+		probeServer = server.NewProbeServer(*healthBindAddress, servers)
+		if err = probeServer.Start(); err != nil {
+			klog.Fatalf("start probe server failed, error is %v", err)
+		}
 	} else {
-		klog.Info("hami-vnpu-core disabled on this node; not starting the vNPU metrics server")
+		metricsServer.Handle("/healthz", server.HealthzHandler(servers))
+		metricsServer.Handle("/readyz", server.ReadyzHandler(servers))
+	}
+	if err = metricsServer.Start(); err != nil {
+		klog.Fatalf("start metrics server failed, error is %v", err)
+	}
+
+	var debugServer *server.DebugServer
+	if *debugBindAddress != "" {
+		debugServer = server.NewDebugServer(*debugBindAddress)
+		debugServer.Handle("/debug/devices", server.DevicesHandler(servers))
+		debugServer.Handle("/debug/config", server.ConfigHandler(servers))
+		debugServer.Handle("/debug/allocate", server.AllocateHistoryHandler(servers))
+		debugServer.Handle("/debug/register", server.RegisterHandler(servers))
+		debugServer.Handle("/debug/faults", server.FaultsHandler(servers))
+		debugServer.Handle("/debug/allocations", server.AllocationsHandler(servers, podResources))
+		if err = debugServer.Start(); err != nil {
+			klog.Fatalf("start debug server failed, error is %v", err)
+		}
 	}
 
-	if err = start(server); err != nil {
+	err = server.Run(servers, server.RunOptions{
+		DevicePluginDir: v1beta1.DevicePluginPath,
+		ConfigDirs:      configDirs(),
+		Reload:          func() bool { return reloadConfig(configured, servers) },
+		Signals:         internal.NewOSWatcher(syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+	if shutdownErr := metricsServer.Shutdown(ctx); shutdownErr != nil {
+		klog.Errorf("shutdown metrics server: %v", shutdownErr)
+	}
+	if debugServer != nil {
+		if shutdownErr := debugServer.Shutdown(ctx); shutdownErr != nil {
+			klog.Errorf("shutdown debug server: %v", shutdownErr)
+		}
+	}
+	if probeServer != nil {
+		if shutdownErr := probeServer.Shutdown(ctx); shutdownErr != nil {
+			klog.Errorf("shutdown probe server: %v", shutdownErr)
+		}
+	}
+	cancel()
+	if err != nil {
 		klog.Fatalf("start PluginServer failed, error is %v", err)
 	}
 }
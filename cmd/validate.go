@@ -0,0 +1,149 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal"
+)
+
+// validateConfigCmd is the subcommand that checks config files offline.
+const validateConfigCmd = "validate-config"
+
+// configValidator decodes one config document and validates it.
+type configValidator func(data []byte, fldPath *field.Path) []error
+
+func validateDeviceConfig(data []byte, fldPath *field.Path) []error {
+	var config internal.Config
+	return decodeAndValidate(data, &config, fldPath, func() field.ErrorList {
+		return internal.ValidateConfig(&config, fldPath)
+	})
+}
+
+func validateNodeConfig(data []byte, fldPath *field.Path) []error {
+	var config internal.NodeListConfig
+	return decodeAndValidate(data, &config, fldPath, func() field.ErrorList {
+		return internal.ValidateNodeConfig(&config, fldPath)
+	})
+}
+
+// decodeAndValidate decodes data into v the way the plugin does and runs
+// validate on it. Unknown keys are not reported: the file is shared with the
+// HAMi scheduler, which reads keys of its own.
+func decodeAndValidate(data []byte, v any, fldPath *field.Path, validate func() field.ErrorList) []error {
+	if err := yaml.Unmarshal(data, v); err != nil {
+		if fldPath != nil {
+			return []error{fmt.Errorf("%s: decode: %w", fldPath, err)}
+		}
+		return []error{fmt.Errorf("decode: %w", err)}
+	}
+	var errs []error
+	for _, err := range validate() {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// validateConfigFile validates the file at path, which is either the config
+// itself or a ConfigMap manifest carrying it in one or more data keys.
+func validateConfigFile(path string, validate configValidator) ([]error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest struct {
+		Kind string            `json:"kind"`
+		Data map[string]string `json:"data"`
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil || manifest.Kind != "ConfigMap" {
+		return validate(data, nil), nil
+	}
+	if len(manifest.Data) == 0 {
+		return []error{field.Required(field.NewPath("data"), "ConfigMap has no data")}, nil
+	}
+	keys := make([]string, 0, len(manifest.Data))
+	for key := range manifest.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var allErrs []error
+	for _, key := range keys {
+		allErrs = append(allErrs, validate([]byte(manifest.Data[key]), field.NewPath("data").Key(key))...)
+	}
+	return allErrs, nil
+}
+
+// runValidateConfig runs the validate-config subcommand and returns its exit
+// code: 0 when every file is valid, 1 when any is not, 2 on usage errors.
+func runValidateConfig(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet(validateConfigCmd, flag.ContinueOnError)
+	fs.SetOutput(out)
+	configPath := fs.String("config_file", "", "device config file or ConfigMap manifest to validate")
+	nodeConfigPath := fs.String("node_config_file", "", "node config file or ConfigMap manifest to validate")
+	fs.Usage = func() {
+		fmt.Fprintf(out, "Usage: %s %s [--config_file FILE] [--node_config_file FILE]\n", os.Args[0], validateConfigCmd)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *configPath == "" && *nodeConfigPath == "" {
+		fmt.Fprintln(out, "nothing to validate, set --config_file and/or --node_config_file")
+		fs.Usage()
+		return 2
+	}
+
+	failed := false
+	for _, f := range []struct {
+		path     string
+		validate configValidator
+	}{
+		{*configPath, validateDeviceConfig},
+		{*nodeConfigPath, validateNodeConfig},
+	} {
+		if f.path == "" {
+			continue
+		}
+		errs, err := validateConfigFile(f.path, f.validate)
+		if err != nil {
+			fmt.Fprintf(out, "%s: %v\n", f.path, err)
+			failed = true
+			continue
+		}
+		if len(errs) == 0 {
+			fmt.Fprintf(out, "%s: OK\n", f.path)
+			continue
+		}
+		failed = true
+		for _, e := range errs {
+			fmt.Fprintf(out, "%s: %v\n", f.path, e)
+		}
+		fmt.Fprintf(out, "%s: %d error(s)\n", f.path, len(errs))
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manager
+
+import (
+	"fmt"
+
+	"ascend-common/devmanager"
+	"ascend-common/devmanager/common"
+	"ascend-common/devmanager/dcmi"
+)
+
+// fakeChip describes one NPU of fakeDeviceManager, keyed by its logic ID.
+type fakeChip struct {
+	name   string
+	uuid   string
+	phyID  int32
+	cardID int32
+	health uint32
+	codes  []int64
+	busID  string
+	// healthErr fails GetDeviceHealth of the chip.
+	healthErr error
+}
+
+// fakeDeviceManager implements the parts of devmanager.DeviceInterface that
+// AscendManager uses. Calling any other method panics on the nil embedded
+// interface, which flags a missing fake in the test.
+type fakeDeviceManager struct {
+	devmanager.DeviceInterface
+	chips map[int32]fakeChip
+	order []int32
+	// vdevs holds the vNPUs per logic ID; nextVDevID is the ID handed out by
+	// the next CreateVirtualDevice.
+	vdevs      map[int32][]common.CgoVDevQueryStru
+	nextVDevID uint32
+	// listErr fails GetDeviceList.
+	listErr error
+}
+
+func newFakeDeviceManager(chips ...fakeChip) *fakeDeviceManager {
+	f := &fakeDeviceManager{chips: map[int32]fakeChip{}, vdevs: map[int32][]common.CgoVDevQueryStru{}, nextVDevID: 100}
+	for i, c := range chips {
+		f.chips[int32(i)] = c
+		f.order = append(f.order, int32(i))
+	}
+	return f
+}
+
+func (f *fakeDeviceManager) chip(logicID int32) (fakeChip, error) {
+	c, ok := f.chips[logicID]
+	if !ok {
+		return fakeChip{}, fmt.Errorf("no device with logic ID %d", logicID)
+	}
+	return c, nil
+}
+
+func (f *fakeDeviceManager) GetDeviceList() (int32, []int32, error) {
+	if f.listErr != nil {
+		return 0, nil, f.listErr
+	}
+	return int32(len(f.order)), f.order, nil
+}
+
+func (f *fakeDeviceManager) GetChipInfo(logicID int32) (*common.ChipInfo, error) {
+	c, err := f.chip(logicID)
+	if err != nil {
+		return nil, err
+	}
+	return &common.ChipInfo{Type: "Ascend", Name: c.name}, nil
+}
+
+func (f *fakeDeviceManager) GetValidChipInfo() (common.ChipInfo, error) {
+	if len(f.order) == 0 {
+		return common.ChipInfo{Type: "Ascend", Name: "910B3"}, nil
+	}
+	c, _ := f.chip(f.order[0])
+	return common.ChipInfo{Type: "Ascend", Name: c.name}, nil
+}
+
+func (f *fakeDeviceManager) GetPhysicIDFromLogicID(logicID int32) (int32, error) {
+	c, err := f.chip(logicID)
+	return c.phyID, err
+}
+
+func (f *fakeDeviceManager) GetCardIDDeviceID(logicID int32) (int32, int32, error) {
+	c, err := f.chip(logicID)
+	return c.cardID, 0, err
+}
+
+func (f *fakeDeviceManager) GetDieID(logicID int32, _ dcmi.DieType) (string, error) {
+	c, err := f.chip(logicID)
+	return c.uuid, err
+}
+
+func (f *fakeDeviceManager) GetPCIeBusInfo(logicID int32) (string, error) {
+	c, err := f.chip(logicID)
+	return c.busID, err
+}
+
+func (f *fakeDeviceManager) GetDeviceHealth(logicID int32) (uint32, error) {
+	c, err := f.chip(logicID)
+	if err == nil {
+		err = c.healthErr
+	}
+	return c.health, err
+}
+
+func (f *fakeDeviceManager) GetDeviceAllErrorCode(logicID int32) (int32, []int64, error) {
+	c, err := f.chip(logicID)
+	return int32(len(c.codes)), c.codes, err
+}
+
+func (f *fakeDeviceManager) CreateVirtualDevice(logicID int32, res common.CgoCreateVDevRes) (common.CgoCreateVDevOut, error) {
+	if _, err := f.chip(logicID); err != nil {
+		return common.CgoCreateVDevOut{}, err
+	}
+	id := f.nextVDevID
+	f.nextVDevID++
+	f.vdevs[logicID] = append(f.vdevs[logicID], common.CgoVDevQueryStru{
+		VDevID:    id,
+		QueryInfo: common.CgoVDevQueryInfo{Name: res.TemplateName},
+	})
+	return common.CgoCreateVDevOut{VDevID: id}, nil
+}
+
+func (f *fakeDeviceManager) GetVirtualDeviceInfo(logicID int32) (common.VirtualDevInfo, error) {
+	return common.VirtualDevInfo{VDevInfo: f.vdevs[logicID]}, nil
+}
+
+func (f *fakeDeviceManager) DestroyVirtualDevice(logicID int32, vDevID uint32) error {
+	vdevs := f.vdevs[logicID]
+	for i, v := range vdevs {
+		if v.VDevID == vDevID {
+			f.vdevs[logicID] = append(vdevs[:i], vdevs[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no vNPU %d on device %d", vDevID, logicID)
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manager
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/klog/v2"
+)
+
+// FaultSeverity classifies the faults currently reported by a chip.
+type FaultSeverity string
+
+const (
+	FaultSeverityNone     FaultSeverity = "None"
+	FaultSeverityMinor    FaultSeverity = "Minor"
+	FaultSeverityMajor    FaultSeverity = "Major"
+	FaultSeverityCritical FaultSeverity = "Critical"
+	// FaultSeverityUnknown is used when the driver reports a health state
+	// outside the documented range, e.g. when the chip no longer answers.
+	FaultSeverityUnknown FaultSeverity = "Unknown"
+)
+
+// Level returns the severity as a number for metrics: 0 for none up to 3 for
+// critical, and 4 for unknown.
+func (s FaultSeverity) Level() int {
+	switch s {
+	case FaultSeverityNone:
+		return 0
+	case FaultSeverityMinor:
+		return 1
+	case FaultSeverityMajor:
+		return 2
+	case FaultSeverityCritical:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// healthSeverity maps the DCMI health state (0 normal, 1 minor alarm, 2 major
+// alarm, 3 critical alarm) to a severity.
+func healthSeverity(health uint32) FaultSeverity {
+	switch health {
+	case 0:
+		return FaultSeverityNone
+	case 1:
+		return FaultSeverityMinor
+	case 2:
+		return FaultSeverityMajor
+	case 3:
+		return FaultSeverityCritical
+	default:
+		return FaultSeverityUnknown
+	}
+}
+
+// classifyFaults returns the highest of the severity of the DCMI health state
+// and those of the chip's error codes. A code's severity is taken from
+// severities; codes not listed there count as minor.
+func classifyFaults(health uint32, codes []int64, severities map[int64]FaultSeverity) FaultSeverity {
+	severity := healthSeverity(health)
+	for _, code := range codes {
+		codeSeverity, ok := severities[code]
+		if !ok {
+			codeSeverity = FaultSeverityMinor
+		}
+		if codeSeverity.Level() > severity.Level() {
+			severity = codeSeverity
+		}
+	}
+	return severity
+}
+
+// parseFaultSeverities turns the faultSeverities of the device config into
+// the table classifyFaults uses. Entries that do not parse are skipped with a
+// warning; validate-config reports them.
+func parseFaultSeverities(m map[string]string) map[int64]FaultSeverity {
+	if len(m) == 0 {
+		return nil
+	}
+	severities := make(map[int64]FaultSeverity, len(m))
+	for code, severity := range m {
+		c, err := strconv.ParseInt(code, 0, 64)
+		if err != nil {
+			klog.Warningf("ignore fault severity of invalid error code %q: %v", code, err)
+			continue
+		}
+		switch s := FaultSeverity(severity); s {
+		case FaultSeverityNone, FaultSeverityMinor, FaultSeverityMajor, FaultSeverityCritical:
+			severities[c] = s
+		default:
+			klog.Warningf("ignore invalid fault severity %q of error code %s", severity, code)
+		}
+	}
+	return severities
+}
+
+// FormatFaultCode renders an error code the way npu-smi prints it.
+func FormatFaultCode(code int64) string {
+	return fmt.Sprintf("0x%X", code)
+}
+
+// FaultCodeStrings renders the device's error codes with FormatFaultCode.
+func (d *Device) FaultCodeStrings() []string {
+	codes := make([]string, 0, len(d.FaultCodes))
+	for _, code := range d.FaultCodes {
+		codes = append(codes, FormatFaultCode(code))
+	}
+	return codes
+}
+
+// getFaultCodes returns the error codes currently reported by the chip. A
+// failure is logged and treated as no codes so that a flaky query does not
+// take the device list down with it.
+func (am *AscendManager) getFaultCodes(logicID int32) []int64 {
+	count, codes, err := am.mgr.GetDeviceAllErrorCode(logicID)
+	if err != nil {
+		klog.Warningf("failed to get error codes of logic ID %d: %v", logicID, err)
+		return nil
+	}
+	if count <= 0 {
+		return nil
+	}
+	if int(count) < len(codes) {
+		codes = codes[:count]
+	}
+	return append([]int64(nil), codes...)
+}
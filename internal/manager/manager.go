@@ -17,7 +17,9 @@
 package manager
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"sort"
 	"sync"
 
@@ -37,6 +39,12 @@ type Device struct {
 	Memory   int64
 	AICore   int32
 	Health   bool
+	// FaultCodes are the error codes the chip currently reports, and
+	// FaultSeverity their classification; see classifyFaults.
+	FaultCodes    []int64
+	FaultSeverity FaultSeverity
+	// NUMANode is the NUMA node the chip is attached to, or NoNUMANode.
+	NUMANode int
 }
 
 // Manager defines the interface that PluginServer depends on.
@@ -49,51 +57,159 @@ type Manager interface {
 	GetDevices() []*Device
 	GetDeviceByUUID(UUID string) *Device
 	GetUnHealthIDs() []int32
-	CleanupIdleVNPUs() error
+	CleanupIdleVNPUs() (int, error)
 	IsHamiVnpuCore() bool
+	PreferredAllocationEnabled() bool
+	CreateVNPUEnabled() bool
+	CreateVNPU(UUID string, template string, owner string) (*VNPU, error)
+	DestroyVNPU(vnpu *VNPU) error
+	RestoreVNPU(vnpu *VNPU)
+	ForgetVNPU(vnpu *VNPU)
+	EffectiveConfig() EffectiveConfig
+	ContainerProfile() internal.ContainerProfile
 }
 
 type AscendManager struct {
 	mu           sync.RWMutex
 	mgr          devmanager.DeviceInterface
+	chipName     string
 	config       internal.VNPUConfig
 	globalConfig internal.Config
 	devs         []*Device
 	nodeConfig   *internal.NodeConfig
+	vnpus        map[vnpuKey]*VNPU
+	podResources PodResourcesLister
 }
 
-func NewAscendManager() (*AscendManager, error) {
+// NewAscendManagers initializes the device manager once and returns one
+// AscendManager per chip name found on the node, so that hosts carrying mixed
+// cards (e.g. 310P next to 910B) serve every chip type from one daemon.
+func NewAscendManagers() ([]*AscendManager, error) {
 	mgr, err := devmanager.AutoInit("", 30)
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto-init device manager: %w", err)
 	}
-	return &AscendManager{
-		mgr:  mgr,
-		devs: []*Device{},
-	}, nil
+	return NewAscendManagersFor(mgr)
+}
+
+// NewAscendManagersFor is NewAscendManagers on top of an already initialized
+// device manager, such as the simulated backend.
+func NewAscendManagersFor(mgr devmanager.DeviceInterface) ([]*AscendManager, error) {
+	chipNames, err := chipNamesOf(mgr)
+	if err != nil {
+		return nil, err
+	}
+	managers := make([]*AscendManager, 0, len(chipNames))
+	for _, name := range chipNames {
+		managers = append(managers, &AscendManager{
+			mgr:      mgr,
+			chipName: name,
+			devs:     []*Device{},
+		})
+	}
+	return managers, nil
+}
+
+// chipNamesOf returns the sorted, distinct chip names of all NPUs on the node.
+// It falls back to GetValidChipInfo when the device list is empty.
+func chipNamesOf(mgr devmanager.DeviceInterface) ([]string, error) {
+	_, IDs, err := mgr.GetDeviceList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device list: %w", err)
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, ID := range IDs {
+		chipInfo, err := mgr.GetChipInfo(ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chip info of logic ID %d: %w", ID, err)
+		}
+		if chipInfo.Type != "Ascend" {
+			return nil, fmt.Errorf("chip type of logic ID %d is not Ascend", ID)
+		}
+		if !seen[chipInfo.Name] {
+			seen[chipInfo.Name] = true
+			names = append(names, chipInfo.Name)
+		}
+	}
+	if len(names) == 0 {
+		chipInfo, err := mgr.GetValidChipInfo()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get valid chip info: %w", err)
+		}
+		if chipInfo.Type != "Ascend" {
+			return nil, fmt.Errorf("chip type is not Ascend")
+		}
+		names = append(names, chipInfo.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ChipName returns the chip name whose devices this manager serves.
+func (am *AscendManager) ChipName() string {
+	return am.chipName
+}
+
+// getDeviceList returns the logic IDs of the NPUs served by this manager,
+// i.e. GetDeviceList() narrowed down to the devices of am.chipName.
+func (am *AscendManager) getDeviceList() ([]int32, error) {
+	_, IDs, err := am.mgr.GetDeviceList()
+	if err != nil {
+		return nil, err
+	}
+	if am.chipName == "" {
+		return IDs, nil
+	}
+	chipIDs := make([]int32, 0, len(IDs))
+	for _, ID := range IDs {
+		chipInfo, err := am.mgr.GetChipInfo(ID)
+		if err != nil {
+			klog.Warningf("failed to get chip info for logic ID %d: %v", ID, err)
+			continue
+		}
+		if chipInfo.Name == am.chipName {
+			chipIDs = append(chipIDs, ID)
+		}
+	}
+	return chipIDs, nil
 }
 
 func (am *AscendManager) LoadNodeConfig(nodePath string, nodeName string) error {
+	nodeConfig, err := findNodeConfig(nodePath, nodeName)
+	if err != nil {
+		return err
+	}
+	am.mu.Lock()
+	am.nodeConfig = nodeConfig
+	am.mu.Unlock()
+	return nil
+}
+
+// findNodeConfig returns the entry of the node config file matching nodeName,
+// or nil when the node has no specific config.
+func findNodeConfig(nodePath string, nodeName string) (*internal.NodeConfig, error) {
 	nodeConfigList, err := internal.LoadNodeConfig(nodePath)
 	if err != nil {
 		klog.Warningf("Failed to load node config from %s: %v", nodePath, err)
-		return err
+		return nil, err
 	}
 
 	for _, n := range nodeConfigList.Nodes {
 		if n.Name == nodeName {
-			am.nodeConfig = &n
 			klog.Infof("Successfully matched node config for %s: %+v", nodeName, n)
-			return nil
+			return &n, nil
 		}
 	}
 
 	klog.Infof("No specific config found for node %s, will use default settings", nodeName)
-	return nil
+	return nil, nil
 }
 
 func (am *AscendManager) shouldIgnoreDevice(uuid string, index int32) bool {
-	if !am.shouldCheckIgnored() {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	if am.nodeConfig == nil || am.nodeConfig.FilterDevices.IsEmpty() {
 		return false
 	}
 
@@ -111,49 +227,138 @@ func (am *AscendManager) shouldIgnoreDevice(uuid string, index int32) bool {
 }
 
 func (am *AscendManager) shouldCheckIgnored() bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
 	return am.nodeConfig != nil && !am.nodeConfig.FilterDevices.IsEmpty()
 }
 
+// filterHasUUID reports whether filterDevices lists any UUID, in which case
+// callers have to look up the die ID before calling shouldIgnoreDevice.
+func (am *AscendManager) filterHasUUID() bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.nodeConfig != nil && am.nodeConfig.FilterDevices.HasUUID()
+}
+
 func (am *AscendManager) LoadConfig(path string) error {
-	config, err := internal.LoadConfig(path)
+	vnpuConfig, config, err := am.loadConfig(path)
 	if err != nil {
-		return fmt.Errorf("failed to load config from %s: %w", path, err)
+		return err
 	}
-	chipInfo, err := am.mgr.GetValidChipInfo()
+	am.mu.Lock()
+	am.config = vnpuConfig
+	am.globalConfig = *config
+	am.mu.Unlock()
+	klog.Infof("load config: %v", vnpuConfig)
+	return nil
+}
+
+// loadConfig reads the device config at path and returns the entry matching
+// this manager's chip together with the whole config.
+func (am *AscendManager) loadConfig(path string) (internal.VNPUConfig, *internal.Config, error) {
+	config, err := internal.LoadConfig(path)
 	if err != nil {
-		return fmt.Errorf("failed to get valid chip info: %w", err)
+		return internal.VNPUConfig{}, nil, fmt.Errorf("failed to load config from %s: %w", path, err)
 	}
-	if chipInfo.Type != "Ascend" {
-		return fmt.Errorf("chip type is not Ascend")
+	chipName := am.chipName
+	if chipName == "" {
+		chipInfo, err := am.mgr.GetValidChipInfo()
+		if err != nil {
+			return internal.VNPUConfig{}, nil, fmt.Errorf("failed to get valid chip info: %w", err)
+		}
+		if chipInfo.Type != "Ascend" {
+			return internal.VNPUConfig{}, nil, fmt.Errorf("chip type is not Ascend")
+		}
+		chipName = chipInfo.Name
 	}
 	idx := -1
 	for i, vnpu := range config.VNPUs.Configs {
-		if vnpu.ChipName == chipInfo.Name {
+		if vnpu.ChipName == chipName {
 			idx = i
 			break
 		}
 	}
 	if idx == -1 {
-		return fmt.Errorf("can not find vnpu config for chip %s", chipInfo.Name)
+		return internal.VNPUConfig{}, nil, fmt.Errorf("can not find vnpu config for chip %s", chipName)
 	}
-	am.config = config.VNPUs.Configs[idx]
-	am.globalConfig = *config
-	sort.Slice(am.config.Templates, func(i, j int) bool {
-		return am.config.Templates[i].Memory < am.config.Templates[j].Memory
+	vnpuConfig := config.VNPUs.Configs[idx]
+	vnpuConfig.Templates = append([]internal.Template(nil), vnpuConfig.Templates...)
+	sort.Slice(vnpuConfig.Templates, func(i, j int) bool {
+		return vnpuConfig.Templates[i].Memory < vnpuConfig.Templates[j].Memory
 	})
-	klog.Infof("load config: %v", am.config)
-	return nil
+	for _, t := range vnpuConfig.Templates {
+		if t.Memory <= 0 {
+			return internal.VNPUConfig{}, nil, fmt.Errorf("template %s of chip %s has invalid memory %d", t.Name, chipName, t.Memory)
+		}
+	}
+	return vnpuConfig, config, nil
+}
+
+// ReloadConfig re-reads the device config and, when nodePath is set, the node
+// config, and swaps them in under the lock. On error the current config is
+// kept; a node config that fails to load only keeps the current node config
+// and still lets the device config through. It reports whether the change requires re-registering with kubelet
+// and HAMi, i.e. whether the resource name, VDeviceCount or filterDevices
+// changed. A changed container profile is picked up by the next Allocate and
+// CDI spec refresh, so it needs no re-registration.
+func (am *AscendManager) ReloadConfig(path string, nodePath string, nodeName string) (bool, error) {
+	vnpuConfig, config, err := am.loadConfig(path)
+	if err != nil {
+		return false, err
+	}
+	am.mu.RLock()
+	nodeConfig := am.nodeConfig
+	am.mu.RUnlock()
+	if nodePath != "" {
+		// A broken node config must not hold back device config changes.
+		if next, err := findNodeConfig(nodePath, nodeName); err != nil {
+			klog.Errorf("reload node config for chip %s failed, keeping the current node config: %v", am.chipName, err)
+		} else {
+			nodeConfig = next
+		}
+	}
+
+	next := &AscendManager{config: vnpuConfig, globalConfig: *config, nodeConfig: nodeConfig}
+	if next.CommonWord() != am.CommonWord() {
+		return false, fmt.Errorf("commonWord of chip %s changed from %s to %s, restart required", am.chipName, am.CommonWord(), next.CommonWord())
+	}
+	changed := next.ResourceName() != am.ResourceName() ||
+		next.VDeviceCount() != am.VDeviceCount() ||
+		!reflect.DeepEqual(next.filterDevices(), am.filterDevices())
+
+	am.mu.Lock()
+	am.config = next.config
+	am.globalConfig = next.globalConfig
+	am.nodeConfig = next.nodeConfig
+	am.mu.Unlock()
+	klog.Infof("reload config: %v, node config: %+v, re-register: %v", vnpuConfig, nodeConfig, changed)
+	return changed, nil
+}
+
+func (am *AscendManager) filterDevices() internal.FilterDevices {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	if am.nodeConfig == nil {
+		return internal.FilterDevices{}
+	}
+	return am.nodeConfig.FilterDevices
 }
 
 func (am *AscendManager) CommonWord() string {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
 	return am.config.CommonWord
 }
 
 func (am *AscendManager) ResourceName() string {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
 	return am.config.ResourceName
 }
 
 func (am *AscendManager) VDeviceCount() int {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
 	// Prefer the per-node override when present, mirroring IsHamiVnpuCore().
 	if am.nodeConfig != nil && am.nodeConfig.VDeviceCount > 0 {
 		return am.nodeConfig.VDeviceCount
@@ -164,54 +369,113 @@ func (am *AscendManager) VDeviceCount() int {
 	return int(am.config.MemoryAllocatable / am.config.Templates[0].Memory)
 }
 
+// UpdateDevice queries the NPUs of the manager. A chip whose query fails
+// keeps its last known record, marked unhealthy, and if the device list
+// itself cannot be read all known chips are marked unhealthy, so that the
+// plugin keeps advertising them instead of stalling. The query errors are
+// returned.
 func (am *AscendManager) UpdateDevice() error {
-	_, IDs, err := am.mgr.GetDeviceList()
+	IDs, err := am.getDeviceList()
 	if err != nil {
 		klog.Errorf("failed to get device list: %v", err)
+		am.markDevicesUnhealthy()
 		return err
 	}
 
+	am.mu.RLock()
+	memory, aiCore := am.config.MemoryAllocatable, am.config.AICore
+	severities := parseFaultSeverities(am.globalConfig.VNPUs.FaultSeverities)
+	// A chip does not move between NUMA nodes, so it is only looked up once.
+	numaNodes := make(map[string]int, len(am.devs))
+	known := make(map[int32]*Device, len(am.devs))
+	for _, dev := range am.devs {
+		numaNodes[dev.UUID] = dev.NUMANode
+		known[dev.LogicID] = dev
+	}
+	am.mu.RUnlock()
+
+	var errs []error
 	newDevs := make([]*Device, 0, len(IDs))
 	for _, ID := range IDs {
-		phyID, err := am.mgr.GetPhysicIDFromLogicID(ID)
+		dev, err := am.queryDevice(ID, memory, aiCore, numaNodes, severities)
 		if err != nil {
-			klog.Errorf("failed to get physic id from logic id: %v", err)
-			return err
-		}
-		cardID, deviceID, err := am.mgr.GetCardIDDeviceID(ID)
-		if err != nil {
-			klog.Errorf("failed to get card id from device id: %v", err)
-			return err
-		}
-		uuid, err := am.mgr.GetDieID(ID, dcmi.VDIE)
-		if err != nil {
-			klog.Errorf("failed to get uuid from device id: %v", err)
-			return err
-		}
-		if am.shouldIgnoreDevice(uuid, cardID) {
-			klog.V(4).Infof("ignore device matched filterDevices uuid=%s index=%d logicID=%d phyID=%d deviceID=%d", uuid, cardID, ID, phyID, deviceID)
+			klog.Errorf("failed to query device %d: %v", ID, err)
+			errs = append(errs, fmt.Errorf("query device %d: %w", ID, err))
+			if prev, ok := known[ID]; ok {
+				newDevs = append(newDevs, unhealthyCopy(prev))
+			}
 			continue
 		}
-		health, err := am.mgr.GetDeviceHealth(ID)
-		if err != nil {
-			klog.Errorf("failed to get device health: %v", err)
-			return err
+		if dev != nil {
+			newDevs = append(newDevs, dev)
 		}
-		newDevs = append(newDevs, &Device{
-			UUID:     uuid,
-			LogicID:  ID,
-			PhyID:    phyID,
-			CardID:   cardID,
-			DeviceID: deviceID,
-			Memory:   am.config.MemoryAllocatable,
-			AICore:   am.config.AICore,
-			Health:   health == 0,
-		})
 	}
 	am.mu.Lock()
 	am.devs = newDevs
 	am.mu.Unlock()
-	return nil
+	return errors.Join(errs...)
+}
+
+// queryDevice reads one NPU from the driver. It returns nil for devices
+// excluded by filterDevices.
+func (am *AscendManager) queryDevice(ID int32, memory int64, aiCore int32, numaNodes map[string]int, severities map[int64]FaultSeverity) (*Device, error) {
+	phyID, err := am.mgr.GetPhysicIDFromLogicID(ID)
+	if err != nil {
+		return nil, fmt.Errorf("get physic id from logic id: %w", err)
+	}
+	cardID, deviceID, err := am.mgr.GetCardIDDeviceID(ID)
+	if err != nil {
+		return nil, fmt.Errorf("get card id from device id: %w", err)
+	}
+	uuid, err := am.mgr.GetDieID(ID, dcmi.VDIE)
+	if err != nil {
+		return nil, fmt.Errorf("get uuid from device id: %w", err)
+	}
+	if am.shouldIgnoreDevice(uuid, cardID) {
+		klog.V(4).Infof("ignore device matched filterDevices uuid=%s index=%d logicID=%d phyID=%d deviceID=%d", uuid, cardID, ID, phyID, deviceID)
+		return nil, nil
+	}
+	health, err := am.mgr.GetDeviceHealth(ID)
+	if err != nil {
+		return nil, fmt.Errorf("get device health: %w", err)
+	}
+	faultCodes := am.getFaultCodes(ID)
+	numaNode, ok := numaNodes[uuid]
+	if !ok {
+		numaNode = am.numaNode(ID)
+	}
+	return &Device{
+		UUID:          uuid,
+		LogicID:       ID,
+		PhyID:         phyID,
+		CardID:        cardID,
+		DeviceID:      deviceID,
+		Memory:        memory,
+		AICore:        aiCore,
+		Health:        health == 0,
+		FaultCodes:    faultCodes,
+		FaultSeverity: classifyFaults(health, faultCodes, severities),
+		NUMANode:      numaNode,
+	}, nil
+}
+
+// unhealthyCopy returns a copy of dev marked unhealthy. Devices are shared
+// with callers of GetDevices, so they are never changed in place.
+func unhealthyCopy(dev *Device) *Device {
+	d := *dev
+	d.Health = false
+	return &d
+}
+
+// markDevicesUnhealthy marks all known devices unhealthy.
+func (am *AscendManager) markDevicesUnhealthy() {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	devs := make([]*Device, 0, len(am.devs))
+	for _, dev := range am.devs {
+		devs = append(devs, unhealthyCopy(dev))
+	}
+	am.devs = devs
 }
 
 func (am *AscendManager) GetDevices() []*Device {
@@ -232,7 +496,7 @@ func (am *AscendManager) GetDeviceByUUID(UUID string) *Device {
 }
 
 func (am *AscendManager) GetIDs() []int32 {
-	_, IDs, err := am.mgr.GetDeviceList()
+	IDs, err := am.getDeviceList()
 	if err != nil {
 		klog.Errorf("failed to get device list: %v", err)
 		return nil
@@ -249,7 +513,7 @@ func (am *AscendManager) GetIDs() []int32 {
 			continue
 		}
 		uuid := ""
-		if am.nodeConfig.FilterDevices.HasUUID() {
+		if am.filterHasUUID() {
 			uuid, err = am.mgr.GetDieID(id, dcmi.VDIE)
 			if err != nil {
 				klog.Warningf("failed to get uuid for logic ID %d: %v", id, err)
@@ -264,7 +528,7 @@ func (am *AscendManager) GetIDs() []int32 {
 }
 
 func (am *AscendManager) GetUnHealthIDs() []int32 {
-	_, IDs, err := am.mgr.GetDeviceList()
+	IDs, err := am.getDeviceList()
 	if err != nil {
 		return nil
 	}
@@ -278,7 +542,7 @@ func (am *AscendManager) GetUnHealthIDs() []int32 {
 				continue
 			}
 			uuid := ""
-			if am.nodeConfig.FilterDevices.HasUUID() {
+			if am.filterHasUUID() {
 				uuid, err = am.mgr.GetDieID(d, dcmi.VDIE)
 				if err != nil {
 					klog.Warningf("failed to get uuid for logic ID %d: %v", d, err)
@@ -301,15 +565,21 @@ func (am *AscendManager) GetUnHealthIDs() []int32 {
 	return unhealthy
 }
 
-func (am *AscendManager) CleanupIdleVNPUs() error {
-	klog.Info("Starting cleanup of idle vNPUs...")
+// CleanupIdleVNPUs destroys the vNPUs no container uses and returns how many
+// it destroyed. When a pod-resources lister is set, a plugin-created vNPU is
+// also kept while kubelet still runs its owner container, even if the driver
+// does not flag it used yet. A vNPU flagged used is never destroyed.
+func (am *AscendManager) CleanupIdleVNPUs() (int, error) {
+	klog.Infof("Starting cleanup of idle vNPUs on %s...", am.CommonWord())
 
-	_, IDs, err := am.mgr.GetDeviceList()
+	IDs, err := am.getDeviceList()
 	if err != nil {
-		return fmt.Errorf("failed to get device list: %w", err)
+		return 0, fmt.Errorf("failed to get device list: %w", err)
 	}
 	klog.Infof("Found %d devices to check for idle vNPUs,%+v", len(IDs), IDs)
 
+	inUse := am.assignedDevices()
+
 	totalCleaned := 0
 	for _, logicID := range IDs {
 		cardID, deviceID, err := am.mgr.GetCardIDDeviceID(logicID)
@@ -318,7 +588,7 @@ func (am *AscendManager) CleanupIdleVNPUs() error {
 			continue
 		}
 		uuid := ""
-		if am.shouldCheckIgnored() && am.nodeConfig.FilterDevices.HasUUID() {
+		if am.filterHasUUID() {
 			uuid, err = am.mgr.GetDieID(logicID, dcmi.VDIE)
 			if err != nil {
 				klog.Warningf("failed to get uuid for logic ID %d: %v", logicID, err)
@@ -341,7 +611,15 @@ func (am *AscendManager) CleanupIdleVNPUs() error {
 		for _, vDev := range vDevInfos.VDevInfo {
 			klog.V(1).Infof("vNPU CardId=%d, VDevID(Vnpu ID)=%d,template=%s,IsContainerUsed=%d", cardID, vDev.VDevID, vDev.QueryInfo.Name, vDev.QueryInfo.IsContainerUsed)
 
-			if vDev.QueryInfo.IsContainerUsed == 0 {
+			idle := vDev.QueryInfo.IsContainerUsed == 0
+			if owner := am.vnpuOwner(logicID, vDev.VDevID); inUse != nil && owner != "" && inUse.owners[owner] {
+				klog.V(1).Infof("Skipping vNPU assigned by kubelet to %s: cardID=%d, deviceID=%d, vnpuID=%d", owner, cardID, deviceID, vDev.VDevID)
+				continue
+			}
+			if idle && am.vnpuInGracePeriod(logicID, vDev.VDevID) {
+				klog.V(1).Infof("Skipping vNPU created for a starting container: cardID=%d, deviceID=%d, vnpuID=%d, template=%s",
+					cardID, deviceID, vDev.VDevID, vDev.QueryInfo.Name)
+			} else if idle {
 				klog.V(1).Infof("Found idle vNPU: cardID=%d, deviceID=%d, vnpuID=%d, status=%d, template=%s,IsContainerUsed=%d",
 					cardID, deviceID, vDev.VDevID, vDev.QueryInfo.Status, vDev.QueryInfo.Name, vDev.QueryInfo.IsContainerUsed)
 
@@ -350,6 +628,7 @@ func (am *AscendManager) CleanupIdleVNPUs() error {
 					klog.Errorf("failed to destroy vNPU %d on device %d: %v", vDev.VDevID, logicID, err)
 				} else {
 					klog.Infof("Successfully destroyed idle vNPU: vnpuID=%d", vDev.VDevID)
+					am.forgetVNPU(logicID, vDev.VDevID)
 					totalCleaned++
 				}
 			} else {
@@ -360,16 +639,69 @@ func (am *AscendManager) CleanupIdleVNPUs() error {
 	}
 
 	klog.Infof("Cleanup completed, destroyed %d idle vNPUs", totalCleaned)
-	return nil
+	return totalCleaned, nil
 }
 
 func (am *AscendManager) GetNodeConfig() *internal.NodeConfig {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
 	return am.nodeConfig
 }
 
 func (am *AscendManager) IsHamiVnpuCore() bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
 	if am.nodeConfig != nil {
 		return am.nodeConfig.HamiVnpuCore
 	}
 	return am.globalConfig.VNPUs.HamiVnpuCore
 }
+
+func (am *AscendManager) PreferredAllocationEnabled() bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.globalConfig.VNPUs.PreferredAllocation
+}
+
+// ContainerProfile returns the chip's container profile with unset fields
+// taken from the default profile.
+func (am *AscendManager) ContainerProfile() internal.ContainerProfile {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return internal.ResolveContainerProfile(am.config.Profile)
+}
+
+// EffectiveConfig is the config a manager currently serves with: the chip's
+// entry of the config file, the node's entry of the node config file, and
+// the values resolved from both.
+type EffectiveConfig struct {
+	ChipName            string               `json:"chipName"`
+	VNPU                internal.VNPUConfig  `json:"vnpu"`
+	Global              internal.Config      `json:"global"`
+	Node                *internal.NodeConfig `json:"node,omitempty"`
+	VDeviceCount        int                  `json:"vDeviceCount"`
+	HamiVnpuCore        bool                 `json:"hamiVnpuCore"`
+	PreferredAllocation bool                 `json:"preferredAllocation"`
+	CreateVNPU          bool                 `json:"createVNPU"`
+}
+
+// EffectiveConfig returns a consistent snapshot of the current config.
+func (am *AscendManager) EffectiveConfig() EffectiveConfig {
+	am.mu.RLock()
+	snap := &AscendManager{chipName: am.chipName, config: am.config, globalConfig: am.globalConfig}
+	if am.nodeConfig != nil {
+		node := *am.nodeConfig
+		snap.nodeConfig = &node
+	}
+	am.mu.RUnlock()
+	return EffectiveConfig{
+		ChipName:            snap.chipName,
+		VNPU:                snap.config,
+		Global:              snap.globalConfig,
+		Node:                snap.nodeConfig,
+		VDeviceCount:        snap.VDeviceCount(),
+		HamiVnpuCore:        snap.IsHamiVnpuCore(),
+		PreferredAllocation: snap.PreferredAllocationEnabled(),
+		CreateVNPU:          snap.CreateVNPUEnabled(),
+	}
+}
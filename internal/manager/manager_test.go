@@ -17,7 +17,14 @@
 package manager
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+
+	"ascend-common/devmanager/common"
 
 	"github.com/Project-HAMi/ascend-device-plugin/internal"
 )
@@ -93,3 +100,397 @@ func TestVDeviceCount(t *testing.T) {
 		})
 	}
 }
+
+// TestEffectiveConfig verifies that the debug snapshot resolves the node
+// overrides the same way the manager does.
+func TestEffectiveConfig(t *testing.T) {
+	am := &AscendManager{
+		chipName: "910B4",
+		config: internal.VNPUConfig{
+			CommonWord:        "Ascend910B4",
+			MemoryAllocatable: 32768,
+			Templates:         []internal.Template{{Name: "vir03_1c_8g", Memory: 8192, AICore: 5}},
+		},
+		globalConfig: internal.Config{VNPUs: internal.VNPUsConfig{PreferredAllocation: true}},
+		nodeConfig:   &internal.NodeConfig{Name: "node-001", HamiVnpuCore: true, VDeviceCount: 8},
+	}
+	got := am.EffectiveConfig()
+	if got.ChipName != "910B4" || got.VNPU.CommonWord != "Ascend910B4" {
+		t.Errorf("EffectiveConfig() = %+v, want chip 910B4 with its vnpu config", got)
+	}
+	if got.VDeviceCount != 8 || !got.HamiVnpuCore || !got.PreferredAllocation || got.CreateVNPU {
+		t.Errorf("EffectiveConfig() = %+v, want vDeviceCount 8, hamiVnpuCore and preferredAllocation", got)
+	}
+	// The snapshot must not alias the manager's node config.
+	got.Node.VDeviceCount = 1
+	if am.VDeviceCount() != 8 {
+		t.Errorf("VDeviceCount() = %d after changing the snapshot, want 8", am.VDeviceCount())
+	}
+}
+
+// TestChipGrouping verifies that a node with mixed cards yields one manager
+// per chip name and that each manager only sees its own devices.
+func TestChipGrouping(t *testing.T) {
+	fake := newFakeDeviceManager(
+		fakeChip{name: "910B3", uuid: "b3-0", phyID: 0, cardID: 0},
+		fakeChip{name: "310P3", uuid: "p3-0", phyID: 1, cardID: 1},
+		fakeChip{name: "910B3", uuid: "b3-1", phyID: 2, cardID: 2, health: 1},
+		fakeChip{name: "310P3", uuid: "p3-1", phyID: 3, cardID: 3},
+	)
+
+	names, err := chipNamesOf(fake)
+	if err != nil {
+		t.Fatalf("chipNamesOf() error: %v", err)
+	}
+	if len(names) != 2 || names[0] != "310P3" || names[1] != "910B3" {
+		t.Fatalf("chipNamesOf() = %v, want [310P3 910B3]", names)
+	}
+
+	tests := []struct {
+		chipName      string
+		wantUUIDs     []string
+		wantUnhealthy []int32
+	}{
+		{chipName: "910B3", wantUUIDs: []string{"b3-0", "b3-1"}, wantUnhealthy: []int32{2}},
+		{chipName: "310P3", wantUUIDs: []string{"p3-0", "p3-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.chipName, func(t *testing.T) {
+			am := &AscendManager{mgr: fake, chipName: tt.chipName}
+			if err := am.UpdateDevice(); err != nil {
+				t.Fatalf("UpdateDevice() error: %v", err)
+			}
+			devs := am.GetDevices()
+			if len(devs) != len(tt.wantUUIDs) {
+				t.Fatalf("GetDevices() returned %d devices, want %d", len(devs), len(tt.wantUUIDs))
+			}
+			for i, d := range devs {
+				if d.UUID != tt.wantUUIDs[i] {
+					t.Fatalf("device[%d].UUID = %q, want %q", i, d.UUID, tt.wantUUIDs[i])
+				}
+			}
+			unhealthy := am.GetUnHealthIDs()
+			if len(unhealthy) != len(tt.wantUnhealthy) {
+				t.Fatalf("GetUnHealthIDs() = %v, want %v", unhealthy, tt.wantUnhealthy)
+			}
+			for i := range unhealthy {
+				if unhealthy[i] != tt.wantUnhealthy[i] {
+					t.Fatalf("GetUnHealthIDs() = %v, want %v", unhealthy, tt.wantUnhealthy)
+				}
+			}
+		})
+	}
+}
+
+// TestReloadConfig verifies that ReloadConfig swaps in the new config and only
+// asks for a restart when the resource name, VDeviceCount or filterDevices
+// change.
+func TestReloadConfig(t *testing.T) {
+	const deviceConfig = `vnpus:
+  configs:
+  - chipName: 910B3
+    commonWord: Ascend910B3
+    resourceName: %s
+    memoryAllocatable: 65536
+    aiCore: 20
+    templates:
+    - name: vir05_1c_16g
+      memory: 16384
+      aiCore: 5
+%s`
+	const nodeConfig = `nodes:
+- name: node-001
+  vDeviceCount: %d
+  filterDevices:
+    index: [%s]
+`
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "device-config.yaml")
+	nodePath := filepath.Join(dir, "node-config.yaml")
+	write := func(path, format string, args ...any) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(fmt.Sprintf(format, args...)), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	write(configPath, deviceConfig, "huawei.com/Ascend910B3", "")
+	write(nodePath, nodeConfig, 0, "")
+	am := &AscendManager{mgr: newFakeDeviceManager(), chipName: "910B3"}
+	if err := am.LoadConfig(configPath); err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if err := am.LoadNodeConfig(nodePath, "node-001"); err != nil {
+		t.Fatalf("LoadNodeConfig() error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		resourceName string
+		vDeviceCount int
+		filterIndex  string
+		profile      string
+		want         bool
+		wantVCount   int
+	}{
+		{name: "unchanged", resourceName: "huawei.com/Ascend910B3", want: false, wantVCount: 4},
+		{name: "resource name", resourceName: "huawei.com/Ascend910B3-x", want: true, wantVCount: 4},
+		{name: "vDeviceCount", resourceName: "huawei.com/Ascend910B3-x", vDeviceCount: 2, want: true, wantVCount: 2},
+		{name: "filterDevices", resourceName: "huawei.com/Ascend910B3-x", vDeviceCount: 2, filterIndex: "1", want: true, wantVCount: 2},
+		{name: "same again", resourceName: "huawei.com/Ascend910B3-x", vDeviceCount: 2, filterIndex: "1", want: false, wantVCount: 2},
+		{name: "profile", resourceName: "huawei.com/Ascend910B3-x", vDeviceCount: 2, filterIndex: "1", profile: "    profile:\n      env: {FOO: bar}\n", want: false, wantVCount: 2},
+	}
+	for _, tt := range tests {
+		write(configPath, deviceConfig, tt.resourceName, tt.profile)
+		write(nodePath, nodeConfig, tt.vDeviceCount, tt.filterIndex)
+		got, err := am.ReloadConfig(configPath, nodePath, "node-001")
+		if err != nil {
+			t.Fatalf("%s: ReloadConfig() error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%s: ReloadConfig() = %v, want %v", tt.name, got, tt.want)
+		}
+		if am.ResourceName() != tt.resourceName {
+			t.Fatalf("%s: ResourceName() = %q, want %q", tt.name, am.ResourceName(), tt.resourceName)
+		}
+		if am.VDeviceCount() != tt.wantVCount {
+			t.Fatalf("%s: VDeviceCount() = %d, want %d", tt.name, am.VDeviceCount(), tt.wantVCount)
+		}
+	}
+
+	if env := am.ContainerProfile().Env; len(env) != 1 || env["FOO"] != "bar" {
+		t.Fatalf("ContainerProfile().Env = %v, want only FOO=bar", env)
+	}
+
+	// An invalid file keeps the current config.
+	write(configPath, "vnpus: [")
+	if _, err := am.ReloadConfig(configPath, nodePath, "node-001"); err == nil {
+		t.Fatal("ReloadConfig() with invalid YAML should fail")
+	}
+	if am.ResourceName() != "huawei.com/Ascend910B3-x" {
+		t.Fatalf("ResourceName() = %q after failed reload, want the previous value", am.ResourceName())
+	}
+
+	// A broken or missing node config keeps the current node config but
+	// does not hold back the device config.
+	for _, nodeFile := range []string{"invalid", "missing"} {
+		resourceName := "huawei.com/Ascend910B3-" + nodeFile
+		write(configPath, deviceConfig, resourceName, "")
+		brokenNodePath := filepath.Join(dir, "missing.yaml")
+		if nodeFile == "invalid" {
+			brokenNodePath = nodePath
+			write(nodePath, "nodes: [")
+		}
+		changed, err := am.ReloadConfig(configPath, brokenNodePath, "node-001")
+		if err != nil {
+			t.Fatalf("%s node config: ReloadConfig() error: %v", nodeFile, err)
+		}
+		if !changed || am.ResourceName() != resourceName {
+			t.Fatalf("%s node config: ReloadConfig() = %v with ResourceName() %q, want true with %q", nodeFile, changed, am.ResourceName(), resourceName)
+		}
+		if am.VDeviceCount() != 2 {
+			t.Fatalf("%s node config: VDeviceCount() = %d, want the previous 2", nodeFile, am.VDeviceCount())
+		}
+	}
+}
+
+// TestCreateVNPU verifies that a vNPU created in Allocate is recorded with its
+// owner and survives CleanupIdleVNPUs until its grace period has passed.
+func TestCreateVNPU(t *testing.T) {
+	fake := newFakeDeviceManager(fakeChip{name: "310P3", uuid: "p3-0"})
+	am := &AscendManager{mgr: fake, chipName: "310P3"}
+	if err := am.UpdateDevice(); err != nil {
+		t.Fatalf("UpdateDevice() error: %v", err)
+	}
+
+	vnpu, err := am.CreateVNPU("p3-0", "vir02", "default/p1/c1")
+	if err != nil {
+		t.Fatalf("CreateVNPU() error: %v", err)
+	}
+	if vnpu.VDevID != 100 || vnpu.Owner != "default/p1/c1" || vnpu.Template != "vir02" {
+		t.Fatalf("CreateVNPU() = %+v", vnpu)
+	}
+	if _, err := am.CreateVNPU("missing", "vir02", "default/p1/c1"); err == nil {
+		t.Fatal("CreateVNPU() on unknown uuid should fail")
+	}
+
+	if _, err := am.CleanupIdleVNPUs(); err != nil {
+		t.Fatalf("CleanupIdleVNPUs() error: %v", err)
+	}
+	if len(fake.vdevs[0]) != 1 || len(am.GetVNPUs()) != 1 {
+		t.Fatal("vNPU within its grace period should survive cleanup")
+	}
+
+	am.vnpus[vnpuKey{logicID: 0, vdevID: 100}].Created = time.Now().Add(-2 * vnpuCreateGracePeriod)
+	if _, err := am.CleanupIdleVNPUs(); err != nil {
+		t.Fatalf("CleanupIdleVNPUs() error: %v", err)
+	}
+	if len(fake.vdevs[0]) != 0 || len(am.GetVNPUs()) != 0 {
+		t.Fatal("idle vNPU past its grace period should be destroyed and forgotten")
+	}
+}
+
+// TestRestoreVNPU checks that a vNPU restored from the checkpoint after a
+// restart gets the grace period of its original creation time.
+func TestRestoreVNPU(t *testing.T) {
+	fake := newFakeDeviceManager(fakeChip{name: "310P3", uuid: "p3-0"})
+	am := &AscendManager{mgr: fake, chipName: "310P3"}
+	if err := am.UpdateDevice(); err != nil {
+		t.Fatalf("UpdateDevice() error: %v", err)
+	}
+	// Created by the previous run of the plugin, which left no record.
+	out, err := fake.CreateVirtualDevice(0, common.CgoCreateVDevRes{TemplateName: "vir02"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := &VNPU{UUID: "p3-0", LogicID: 0, VDevID: out.VDevID, Template: "vir02", Owner: "default/p1/c1", Created: time.Now()}
+	am.RestoreVNPU(restored)
+	if _, err := am.CleanupIdleVNPUs(); err != nil {
+		t.Fatalf("CleanupIdleVNPUs() error: %v", err)
+	}
+	if len(fake.vdevs[0]) != 1 {
+		t.Fatal("restored vNPU within its grace period should survive cleanup")
+	}
+
+	am.ForgetVNPU(restored)
+	if _, err := am.CleanupIdleVNPUs(); err != nil {
+		t.Fatalf("CleanupIdleVNPUs() error: %v", err)
+	}
+	if len(fake.vdevs[0]) != 0 {
+		t.Fatal("forgotten idle vNPU should be destroyed")
+	}
+}
+
+func TestUpdateDeviceFaults(t *testing.T) {
+	fake := newFakeDeviceManager(
+		fakeChip{name: "910B3", uuid: "ok"},
+		fakeChip{name: "910B3", uuid: "warn", codes: []int64{0x80E01801}},
+		fakeChip{name: "910B3", uuid: "major", health: 2, codes: []int64{0x80CB8009, 0x80E18402}},
+		fakeChip{name: "910B3", uuid: "gone", health: 0xffffffff},
+		fakeChip{name: "910B3", uuid: "critical-code", health: 1, codes: []int64{0x80E01801, 0x80C98008}},
+		fakeChip{name: "910B3", uuid: "ignored-code", codes: []int64{0x80CB8009}},
+	)
+	am := &AscendManager{mgr: fake, chipName: "910B3"}
+	am.globalConfig.VNPUs.FaultSeverities = map[string]string{"0x80C98008": "Critical", "0x80CB8009": "None"}
+	if err := am.UpdateDevice(); err != nil {
+		t.Fatalf("UpdateDevice() error: %v", err)
+	}
+
+	want := map[string]struct {
+		health   bool
+		severity FaultSeverity
+		codes    []string
+	}{
+		"ok":    {health: true, severity: FaultSeverityNone, codes: []string{}},
+		"warn":  {health: true, severity: FaultSeverityMinor, codes: []string{"0x80E01801"}},
+		"major": {health: false, severity: FaultSeverityMajor, codes: []string{"0x80CB8009", "0x80E18402"}},
+		"gone":  {health: false, severity: FaultSeverityUnknown, codes: []string{}},
+		// A listed code raises the severity above the health state's.
+		"critical-code": {health: false, severity: FaultSeverityCritical, codes: []string{"0x80E01801", "0x80C98008"}},
+		// A code listed as None does not count as a fault.
+		"ignored-code": {health: true, severity: FaultSeverityNone, codes: []string{"0x80CB8009"}},
+	}
+	for _, dev := range am.GetDevices() {
+		w := want[dev.UUID]
+		if dev.Health != w.health || dev.FaultSeverity != w.severity || !reflect.DeepEqual(dev.FaultCodeStrings(), w.codes) {
+			t.Errorf("device %s: health=%v severity=%s codes=%v, want health=%v severity=%s codes=%v",
+				dev.UUID, dev.Health, dev.FaultSeverity, dev.FaultCodeStrings(), w.health, w.severity, w.codes)
+		}
+	}
+}
+
+// TestUpdateDeviceNUMA verifies that UpdateDevice reads each chip's NUMA node
+// from sysfs once, and reports NoNUMANode when it is unknown.
+// TestUpdateDeviceQueryErrors checks that failed queries keep the last known
+// devices, marked unhealthy, instead of dropping them.
+func TestUpdateDeviceQueryErrors(t *testing.T) {
+	fake := newFakeDeviceManager(
+		fakeChip{name: "910B3", uuid: "a"},
+		fakeChip{name: "910B3", uuid: "b"},
+	)
+	am := &AscendManager{mgr: fake, chipName: "910B3"}
+	if err := am.UpdateDevice(); err != nil {
+		t.Fatalf("UpdateDevice() error: %v", err)
+	}
+
+	health := func() map[string]bool {
+		h := map[string]bool{}
+		for _, d := range am.GetDevices() {
+			h[d.UUID] = d.Health
+		}
+		return h
+	}
+
+	chip := fake.chips[1]
+	chip.healthErr = fmt.Errorf("dcmi timeout")
+	fake.chips[1] = chip
+	if err := am.UpdateDevice(); err == nil {
+		t.Fatal("UpdateDevice() with a failing chip should return its error")
+	}
+	if got, want := health(), map[string]bool{"a": true, "b": false}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("health after chip query error = %v, want %v", got, want)
+	}
+
+	fake.listErr = fmt.Errorf("dcmi not ready")
+	if err := am.UpdateDevice(); err == nil {
+		t.Fatal("UpdateDevice() with a failing device list should return its error")
+	}
+	if got, want := health(), map[string]bool{"a": false, "b": false}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("health after device list error = %v, want %v", got, want)
+	}
+
+	fake.listErr = nil
+	chip.healthErr = nil
+	fake.chips[1] = chip
+	if err := am.UpdateDevice(); err != nil {
+		t.Fatalf("UpdateDevice() error: %v", err)
+	}
+	if got, want := health(), map[string]bool{"a": true, "b": true}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("health after recovery = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateDeviceNUMA(t *testing.T) {
+	dir := t.TempDir()
+	orig := sysfsPCIDevicesPath
+	sysfsPCIDevicesPath = dir
+	t.Cleanup(func() { sysfsPCIDevicesPath = orig })
+	writeNUMANode := func(busID, node string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, busID), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, busID, "numa_node"), []byte(node+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeNUMANode("0000:c1:00.0", "1")
+	writeNUMANode("0000:01:00.0", "-1")
+
+	fake := newFakeDeviceManager(
+		// DCMI reports bus IDs in upper case, padded with spaces.
+		fakeChip{name: "910B3", uuid: "socket1", busID: "0000:C1:00.0   "},
+		fakeChip{name: "910B3", uuid: "no-affinity", busID: "0000:01:00.0"},
+		fakeChip{name: "910B3", uuid: "not-in-sysfs", busID: "0000:02:00.0"},
+		fakeChip{name: "910B3", uuid: "no-bus-info"},
+	)
+	am := &AscendManager{mgr: fake, chipName: "910B3"}
+	want := map[string]int{"socket1": 1, "no-affinity": NoNUMANode, "not-in-sysfs": NoNUMANode, "no-bus-info": NoNUMANode}
+	check := func(when string) {
+		t.Helper()
+		if err := am.UpdateDevice(); err != nil {
+			t.Fatalf("UpdateDevice() error: %v", err)
+		}
+		for _, dev := range am.GetDevices() {
+			if dev.NUMANode != want[dev.UUID] {
+				t.Errorf("%s: device %s NUMANode = %d, want %d", when, dev.UUID, dev.NUMANode, want[dev.UUID])
+			}
+		}
+	}
+	check("first update")
+
+	// Later updates keep the node found first instead of reading sysfs again.
+	writeNUMANode("0000:c1:00.0", "0")
+	check("second update")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// NoNUMANode is the NUMA node of devices without NUMA affinity, as the kernel
+// reports it for single-node machines and unknown devices.
+const NoNUMANode = -1
+
+// sysfsPCIDevicesPath is where the kernel lists PCI devices by bus ID.
+var sysfsPCIDevicesPath = "/sys/bus/pci/devices"
+
+// numaNodeOfPCIDevice reads the NUMA node of the PCI device with the given
+// bus ID, e.g. 0000:c1:00.0, from sysfs.
+func numaNodeOfPCIDevice(busID string) (int, error) {
+	// DCMI reports the bus ID in upper case and padded with spaces.
+	busID = strings.ToLower(strings.TrimSpace(busID))
+	if busID == "" {
+		return NoNUMANode, fmt.Errorf("empty PCIe bus ID")
+	}
+	data, err := os.ReadFile(filepath.Join(sysfsPCIDevicesPath, busID, "numa_node"))
+	if err != nil {
+		return NoNUMANode, err
+	}
+	node, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return NoNUMANode, fmt.Errorf("parse numa_node of %s: %w", busID, err)
+	}
+	if node < 0 {
+		return NoNUMANode, nil
+	}
+	return node, nil
+}
+
+// numaNode returns the NUMA node of the NPU with the given logic ID, or
+// NoNUMANode if it cannot be found. Failures only cost the topology hint, so
+// they are logged rather than returned.
+func (am *AscendManager) numaNode(logicID int32) int {
+	busID, err := am.mgr.GetPCIeBusInfo(logicID)
+	if err != nil {
+		klog.Warningf("failed to get PCIe bus info of logic id %d, reporting no NUMA affinity: %v", logicID, err)
+		return NoNUMANode
+	}
+	node, err := numaNodeOfPCIDevice(busID)
+	if err != nil {
+		klog.Warningf("failed to get NUMA node of logic id %d, reporting no NUMA affinity: %v", logicID, err)
+		return NoNUMANode
+	}
+	return node
+}
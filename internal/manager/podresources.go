@@ -0,0 +1,173 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/klog/v2"
+	podresourcesv1 "k8s.io/kubelet/pkg/apis/podresources/v1"
+)
+
+const (
+	// PodResourcesSocket is kubelet's pod-resources endpoint.
+	PodResourcesSocket = "/var/lib/kubelet/pod-resources/kubelet.sock"
+
+	podResourcesTimeout = 10 * time.Second
+	// podResourcesMaxMsgSize matches kubelet's own client limit; nodes with
+	// many pods exceed grpc's 4MB default.
+	podResourcesMaxMsgSize = 16 * 1024 * 1024
+)
+
+// ContainerDevices is the set of device IDs of one resource that kubelet
+// assigned to a container.
+type ContainerDevices struct {
+	Namespace    string
+	Pod          string
+	Container    string
+	ResourceName string
+	DeviceIDs    []string
+}
+
+// Owner returns the container in the "<namespace>/<pod>/<container>" form
+// used as VNPU.Owner.
+func (c ContainerDevices) Owner() string {
+	return fmt.Sprintf("%s/%s/%s", c.Namespace, c.Pod, c.Container)
+}
+
+// PodResourcesLister lists the devices kubelet assigned to containers.
+type PodResourcesLister interface {
+	ListContainerDevices(ctx context.Context) ([]ContainerDevices, error)
+}
+
+// PodResourcesClient queries kubelet's pod-resources API. It dials per call so
+// that a kubelet restart needs no reconnect handling.
+type PodResourcesClient struct {
+	socket string
+}
+
+func NewPodResourcesClient(socket string) *PodResourcesClient {
+	return &PodResourcesClient{socket: socket}
+}
+
+func (c *PodResourcesClient) ListContainerDevices(ctx context.Context) ([]ContainerDevices, error) {
+	ctx, cancel := context.WithTimeout(ctx, podResourcesTimeout)
+	defer cancel()
+
+	conn, err := grpc.NewClient("passthrough:///"+c.socket,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(podResourcesMaxMsgSize)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connect to pod-resources socket %s: %w", c.socket, err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	resp, err := podresourcesv1.NewPodResourcesListerClient(conn).List(ctx, &podresourcesv1.ListPodResourcesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("list pod resources: %w", err)
+	}
+	return flattenPodResources(resp), nil
+}
+
+// flattenPodResources turns kubelet's per-pod response into one entry per
+// container and resource.
+func flattenPodResources(resp *podresourcesv1.ListPodResourcesResponse) []ContainerDevices {
+	var out []ContainerDevices
+	for _, pod := range resp.GetPodResources() {
+		for _, ctr := range pod.GetContainers() {
+			for _, dev := range ctr.GetDevices() {
+				if len(dev.GetDeviceIds()) == 0 {
+					continue
+				}
+				out = append(out, ContainerDevices{
+					Namespace:    pod.GetNamespace(),
+					Pod:          pod.GetName(),
+					Container:    ctr.GetName(),
+					ResourceName: dev.GetResourceName(),
+					DeviceIDs:    dev.GetDeviceIds(),
+				})
+			}
+		}
+	}
+	return out
+}
+
+// DeviceUUIDFromID returns the device UUID of a kubelet device ID
+// "<uuid>-<slot>" as advertised by ListAndWatch.
+func DeviceUUIDFromID(id string) string {
+	i := strings.LastIndex(id, "-")
+	if i < 0 {
+		return id
+	}
+	return id[:i]
+}
+
+// deviceAssignments is kubelet's view of which containers hold the devices of
+// one resource. kubelet only knows the "<uuid>-<slot>" IDs advertised by
+// ListAndWatch, not the NPUs Allocate serves from the HAMi annotations, so it
+// only tells which containers are still alive.
+type deviceAssignments struct {
+	// owners holds the "<namespace>/<pod>/<container>" of every container
+	// with devices of the resource.
+	owners map[string]bool
+}
+
+// SetPodResourcesLister makes the manager consult kubelet's device assignments
+// when deciding whether a vNPU is idle.
+func (am *AscendManager) SetPodResourcesLister(lister PodResourcesLister) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.podResources = lister
+}
+
+// assignedDevices returns kubelet's assignments of this manager's resource,
+// or nil when no lister is set or kubelet could not be queried.
+func (am *AscendManager) assignedDevices() *deviceAssignments {
+	am.mu.RLock()
+	lister := am.podResources
+	am.mu.RUnlock()
+	if lister == nil {
+		return nil
+	}
+	all, err := lister.ListContainerDevices(context.Background())
+	if err != nil {
+		klog.Warningf("query kubelet pod-resources, falling back to vNPU usage flags: %v", err)
+		return nil
+	}
+	resourceName := am.ResourceName()
+	assigned := &deviceAssignments{owners: map[string]bool{}}
+	for _, c := range all {
+		if c.ResourceName != resourceName {
+			continue
+		}
+		assigned.owners[c.Owner()] = true
+	}
+	return assigned
+}
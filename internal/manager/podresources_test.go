@@ -0,0 +1,121 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"ascend-common/devmanager/common"
+
+	podresourcesv1 "k8s.io/kubelet/pkg/apis/podresources/v1"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal"
+)
+
+// fakePodResources returns a fixed list of assignments, or err.
+type fakePodResources struct {
+	devices []ContainerDevices
+	err     error
+}
+
+func (f *fakePodResources) ListContainerDevices(context.Context) ([]ContainerDevices, error) {
+	return f.devices, f.err
+}
+
+func TestFlattenPodResources(t *testing.T) {
+	resp := &podresourcesv1.ListPodResourcesResponse{
+		PodResources: []*podresourcesv1.PodResources{{
+			Name:      "p1",
+			Namespace: "default",
+			Containers: []*podresourcesv1.ContainerResources{
+				{
+					Name: "c1",
+					Devices: []*podresourcesv1.ContainerDevices{
+						{ResourceName: "huawei.com/Ascend310P", DeviceIds: []string{"npu0-0", "npu0-1", "npu1-0"}},
+						{ResourceName: "nvidia.com/gpu"},
+					},
+				},
+				{Name: "sidecar"},
+			},
+		}},
+	}
+	got := flattenPodResources(resp)
+	want := []ContainerDevices{{
+		Namespace: "default", Pod: "p1", Container: "c1",
+		ResourceName: "huawei.com/Ascend310P", DeviceIDs: []string{"npu0-0", "npu0-1", "npu1-0"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("flattenPodResources() = %+v, want %+v", got, want)
+	}
+	if owner := got[0].Owner(); owner != "default/p1/c1" {
+		t.Fatalf("Owner() = %q, want default/p1/c1", owner)
+	}
+}
+
+// TestCleanupIdleVNPUsWithPodResources checks that kubelet's assignments keep
+// the vNPUs of live containers, and never free a vNPU the driver flags used.
+func TestCleanupIdleVNPUsWithPodResources(t *testing.T) {
+	fake := newFakeDeviceManager(
+		fakeChip{name: "310P3", uuid: "npu0"},
+		fakeChip{name: "310P3", uuid: "npu1"},
+	)
+	am := &AscendManager{
+		mgr:      fake,
+		chipName: "310P3",
+		config:   internal.VNPUConfig{ResourceName: "huawei.com/Ascend310P"},
+	}
+	if err := am.UpdateDevice(); err != nil {
+		t.Fatalf("UpdateDevice() error: %v", err)
+	}
+
+	// vNPU 100 on npu0 was created by the plugin long ago for a container that
+	// has not marked it used yet, but kubelet still assigns it devices.
+	owned, err := am.CreateVNPU("npu0", "vir02", "default/p1/c1")
+	if err != nil {
+		t.Fatalf("CreateVNPU() error: %v", err)
+	}
+	owned.Created = time.Now().Add(-2 * vnpuCreateGracePeriod)
+	// vNPU 101 on npu1 is flagged as used. kubelet lists no device ID of
+	// npu1, but its IDs are only slots, so that must not free the vNPU.
+	if _, err := fake.CreateVirtualDevice(1, common.CgoCreateVDevRes{TemplateName: "vir02"}); err != nil {
+		t.Fatal(err)
+	}
+	fake.vdevs[1][0].QueryInfo.IsContainerUsed = 1
+
+	am.SetPodResourcesLister(&fakePodResources{devices: []ContainerDevices{{
+		Namespace: "default", Pod: "p1", Container: "c1",
+		ResourceName: "huawei.com/Ascend310P", DeviceIDs: []string{"npu0-0"},
+	}}})
+	cleaned, err := am.CleanupIdleVNPUs()
+	if err != nil {
+		t.Fatalf("CleanupIdleVNPUs() error: %v", err)
+	}
+	if cleaned != 0 || len(fake.vdevs[0]) != 1 || len(fake.vdevs[1]) != 1 {
+		t.Fatalf("cleaned=%d vdevs=%v, want both vNPUs kept", cleaned, fake.vdevs)
+	}
+
+	// Without kubelet's answer the flags decide again: the owned vNPU is idle
+	// and past its grace period.
+	am.SetPodResourcesLister(&fakePodResources{err: fmt.Errorf("kubelet down")})
+	if cleaned, err = am.CleanupIdleVNPUs(); err != nil || cleaned != 1 {
+		t.Fatalf("CleanupIdleVNPUs() = %d, %v, want 1 destroyed", cleaned, err)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manager
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"ascend-common/devmanager/common"
+
+	"k8s.io/klog/v2"
+)
+
+// vnpuCreateGracePeriod protects a vNPU created in Allocate from
+// CleanupIdleVNPUs until its container had time to start and mark it used.
+const vnpuCreateGracePeriod = 5 * time.Minute
+
+// VNPU is a hard-slice vNPU created by the plugin during Allocate.
+type VNPU struct {
+	UUID     string
+	LogicID  int32
+	VDevID   uint32
+	Template string
+	// Owner identifies the container the vNPU was created for, as
+	// "<namespace>/<pod>/<container>".
+	Owner   string
+	Created time.Time
+}
+
+type vnpuKey struct {
+	logicID int32
+	vdevID  uint32
+}
+
+// CreateVNPUEnabled reports whether hard-slice vNPUs are created by the plugin
+// in Allocate instead of by the Ascend runtime from ASCEND_VNPU_SPECS.
+func (am *AscendManager) CreateVNPUEnabled() bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.globalConfig.VNPUs.CreateVNPU
+}
+
+// CreateVNPU creates a vNPU from template on the NPU with the given UUID and
+// records owner as the container it belongs to.
+func (am *AscendManager) CreateVNPU(UUID string, template string, owner string) (*VNPU, error) {
+	dev := am.GetDeviceByUUID(UUID)
+	if dev == nil {
+		return nil, fmt.Errorf("unknown uuid: %s", UUID)
+	}
+	out, err := am.mgr.CreateVirtualDevice(dev.LogicID, common.CgoCreateVDevRes{
+		// MaxUint32 lets the driver pick the vNPU ID and group.
+		VDevID:       math.MaxUint32,
+		VfgID:        math.MaxUint32,
+		TemplateName: template,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create vNPU %s on device %d: %w", template, dev.LogicID, err)
+	}
+	vnpu := &VNPU{
+		UUID:     UUID,
+		LogicID:  dev.LogicID,
+		VDevID:   out.VDevID,
+		Template: template,
+		Owner:    owner,
+		Created:  time.Now(),
+	}
+	am.mu.Lock()
+	if am.vnpus == nil {
+		am.vnpus = map[vnpuKey]*VNPU{}
+	}
+	am.vnpus[vnpuKey{logicID: dev.LogicID, vdevID: out.VDevID}] = vnpu
+	am.mu.Unlock()
+	klog.Infof("created vNPU %d (%s) on device %d for %s", out.VDevID, template, dev.LogicID, owner)
+	return vnpu, nil
+}
+
+// DestroyVNPU destroys a vNPU created by CreateVNPU and drops its record.
+func (am *AscendManager) DestroyVNPU(vnpu *VNPU) error {
+	if err := am.mgr.DestroyVirtualDevice(vnpu.LogicID, vnpu.VDevID); err != nil {
+		return fmt.Errorf("destroy vNPU %d on device %d: %w", vnpu.VDevID, vnpu.LogicID, err)
+	}
+	am.forgetVNPU(vnpu.LogicID, vnpu.VDevID)
+	return nil
+}
+
+// RestoreVNPU records a vNPU created by an earlier run of the plugin, as read
+// back from the allocation checkpoint, so that CleanupIdleVNPUs knows its
+// owner and creation time again.
+func (am *AscendManager) RestoreVNPU(vnpu *VNPU) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if am.vnpus == nil {
+		am.vnpus = map[vnpuKey]*VNPU{}
+	}
+	am.vnpus[vnpuKey{logicID: vnpu.LogicID, vdevID: vnpu.VDevID}] = vnpu
+}
+
+// ForgetVNPU drops the record of a vNPU whose container is gone without
+// destroying it; CleanupIdleVNPUs then destroys it once the driver reports it
+// idle.
+func (am *AscendManager) ForgetVNPU(vnpu *VNPU) {
+	am.forgetVNPU(vnpu.LogicID, vnpu.VDevID)
+}
+
+// GetVNPUs returns the vNPUs created by the plugin that still exist.
+func (am *AscendManager) GetVNPUs() []*VNPU {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	vnpus := make([]*VNPU, 0, len(am.vnpus))
+	for _, v := range am.vnpus {
+		vnpus = append(vnpus, v)
+	}
+	return vnpus
+}
+
+// vnpuInGracePeriod reports whether the vNPU was created by the plugin so
+// recently that its container may not have started yet.
+func (am *AscendManager) vnpuInGracePeriod(logicID int32, vdevID uint32) bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	v, ok := am.vnpus[vnpuKey{logicID: logicID, vdevID: vdevID}]
+	return ok && time.Since(v.Created) < vnpuCreateGracePeriod
+}
+
+// vnpuOwner returns the owner of a vNPU created by the plugin, or "" for
+// vNPUs the plugin did not create.
+func (am *AscendManager) vnpuOwner(logicID int32, vdevID uint32) string {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	if v, ok := am.vnpus[vnpuKey{logicID: logicID, vdevID: vdevID}]; ok {
+		return v.Owner
+	}
+	return ""
+}
+
+func (am *AscendManager) forgetVNPU(logicID int32, vdevID uint32) {
+	am.mu.Lock()
+	delete(am.vnpus, vnpuKey{logicID: logicID, vdevID: vdevID})
+	am.mu.Unlock()
+}
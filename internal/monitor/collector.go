@@ -7,94 +7,287 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
 )
 
 var (
-	hostGPUdesc = prometheus.NewDesc(
-		"hami_host_gpu_memory_used_bytes",
-		"GPU device memory usage in bytes",
+	npuMemoryUsedDesc = prometheus.NewDesc(
+		"hami_npu_memory_used_bytes",
+		"NPU device memory usage in bytes",
 		[]string{"device_index", "device_uuid", "device_type"}, nil,
 	)
 
-	hostGPUUtilizationdesc = prometheus.NewDesc(
-		"hami_host_gpu_utilization_ratio",
-		"GPU core utilization ratio (0-100)",
+	npuAICoreUtilizationDesc = prometheus.NewDesc(
+		"hami_npu_aicore_utilization_ratio",
+		"NPU AI Core utilization ratio (0-100)",
 		[]string{"device_index", "device_uuid", "device_type"}, nil,
 	)
 
-	ctrvGPUdesc = prometheus.NewDesc(
-		"hami_vgpu_memory_used_bytes",
-		"vGPU device memory usage in bytes",
+	npuTemperatureDesc = prometheus.NewDesc(
+		"hami_npu_temperature_celsius",
+		"NPU chip temperature in degrees Celsius",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	)
+
+	npuPowerDesc = prometheus.NewDesc(
+		"hami_npu_power_watts",
+		"NPU chip power draw in watts",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	)
+
+	npuHBMTotalDesc = prometheus.NewDesc(
+		"hami_npu_hbm_total_bytes",
+		"NPU HBM size in bytes",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	)
+
+	npuHBMUsedDesc = prometheus.NewDesc(
+		"hami_npu_hbm_used_bytes",
+		"NPU HBM usage in bytes",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	)
+
+	npuHBMBandwidthDesc = prometheus.NewDesc(
+		"hami_npu_hbm_bandwidth_utilization_ratio",
+		"NPU HBM bandwidth utilization ratio (0-100)",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	)
+
+	npuAICPUUtilizationDesc = prometheus.NewDesc(
+		"hami_npu_aicpu_utilization_ratio",
+		"NPU AI CPU utilization ratio (0-100)",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	)
+
+	npuVectorCoreUtilizationDesc = prometheus.NewDesc(
+		"hami_npu_vector_core_utilization_ratio",
+		"NPU vector core utilization ratio (0-100)",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	)
+
+	npuECCErrorsDesc = prometheus.NewDesc(
+		"hami_npu_ecc_errors_total",
+		"NPU HBM ECC errors since the driver started",
+		[]string{"device_index", "device_uuid", "device_type", "type"}, nil,
+	)
+
+	npuECCIsolatedPagesDesc = prometheus.NewDesc(
+		"hami_npu_ecc_isolated_pages",
+		"NPU HBM pages isolated after ECC errors",
+		[]string{"device_index", "device_uuid", "device_type", "type"}, nil,
+	)
+
+	npuHealthDesc = prometheus.NewDesc(
+		"hami_npu_health",
+		"NPU health code reported by the driver (0 normal, 1 minor, 2 major, 3 critical)",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	)
+
+	vnpuMemoryUsedDesc = prometheus.NewDesc(
+		"hami_vnpu_memory_used_bytes",
+		"vNPU device memory usage in bytes",
 		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
 	)
 
-	ctrvGPUlimitdesc = prometheus.NewDesc(
-		"hami_vgpu_memory_limit_bytes",
-		"vGPU device memory limit in bytes",
+	vnpuMemoryLimitDesc = prometheus.NewDesc(
+		"hami_vnpu_memory_limit_bytes",
+		"vNPU device memory limit in bytes",
 		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
 	)
 
-	ctrDeviceUtilizationdesc = prometheus.NewDesc(
-		"hami_container_device_utilization_ratio",
-		"Container device SM utilization ratio",
+	vnpuAICoreUtilizationDesc = prometheus.NewDesc(
+		"hami_vnpu_aicore_utilization_ratio",
+		"AI Core utilization ratio (0-100) of the NPU the container runs on",
 		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
 	)
 
-	ctrDeviceMemoryContextDesc = prometheus.NewDesc(
-		"hami_vgpu_memory_context_bytes",
+	vnpuMemoryContextDesc = prometheus.NewDesc(
+		"hami_vnpu_memory_context_bytes",
 		"Container device memory context size in bytes",
 		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
 	)
 
-	ctrDeviceMemoryModuleDesc = prometheus.NewDesc(
-		"hami_vgpu_memory_module_bytes",
+	vnpuMemoryModuleDesc = prometheus.NewDesc(
+		"hami_vnpu_memory_module_bytes",
 		"Container device memory module size in bytes",
 		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
 	)
 
-	ctrDeviceMemoryBufferDesc = prometheus.NewDesc(
-		"hami_vgpu_memory_buffer_bytes",
+	vnpuMemoryBufferDesc = prometheus.NewDesc(
+		"hami_vnpu_memory_buffer_bytes",
 		"Container device memory buffer size in bytes",
 		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
 	)
+
+	processMemoryDesc = prometheus.NewDesc(
+		"hami_vnpu_process_memory_used_bytes",
+		"HBM used by one process of the container in bytes",
+		[]string{"namespace", "pod", "container", "pid", "vdevice_index", "device_uuid"}, nil,
+	)
+
+	ctrComputePriorityDesc = prometheus.NewDesc(
+		"hami_vnpu_compute_priority",
+		"Compute priority the hami-vnpu-core limiter runs the container at",
+		[]string{"namespace", "pod", "container"}, nil,
+	)
+
+	workerCoreUtilizationDesc = prometheus.NewDesc(
+		"hami_vnpu_worker_core_utilization_ratio",
+		"AICore utilization (0-100) last reported by a worker process",
+		[]string{"namespace", "pod", "container", "pid", "vdevice_index", "device_uuid"}, nil,
+	)
+
+	workerThrottledDesc = prometheus.NewDesc(
+		"hami_vnpu_worker_throttled_seconds_total",
+		"Time the limiter held a worker process back",
+		[]string{"namespace", "pod", "container", "pid", "vdevice_index", "device_uuid"}, nil,
+	)
+
+	workerReportTimestampDesc = prometheus.NewDesc(
+		"hami_vnpu_worker_last_report_timestamp_seconds",
+		"Unix time of the last report of a worker process",
+		[]string{"namespace", "pod", "container", "pid", "vdevice_index", "device_uuid"}, nil,
+	)
 )
 
-type vNPUCollector struct {
+// shmemUnknownLayouts counts container shmem files skipped because their
+// layout is not known.
+var shmemUnknownLayouts = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "hami_vnpu_shmem_unknown_layout_total",
+	Help: "Container shmem files skipped because their layout is unknown",
+})
+
+// legacyGPUDescs maps NPU metrics to the GPU-named metrics HAMi dashboards
+// were built on. They are emitted alongside when legacy names are enabled.
+var legacyGPUDescs = map[*prometheus.Desc]*prometheus.Desc{
+	npuMemoryUsedDesc: prometheus.NewDesc(
+		"hami_host_gpu_memory_used_bytes",
+		"Deprecated alias of hami_npu_memory_used_bytes",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	),
+	npuAICoreUtilizationDesc: prometheus.NewDesc(
+		"hami_host_gpu_utilization_ratio",
+		"Deprecated alias of hami_npu_aicore_utilization_ratio",
+		[]string{"device_index", "device_uuid", "device_type"}, nil,
+	),
+	vnpuMemoryUsedDesc: prometheus.NewDesc(
+		"hami_vgpu_memory_used_bytes",
+		"Deprecated alias of hami_vnpu_memory_used_bytes",
+		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
+	),
+	vnpuMemoryLimitDesc: prometheus.NewDesc(
+		"hami_vgpu_memory_limit_bytes",
+		"Deprecated alias of hami_vnpu_memory_limit_bytes",
+		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
+	),
+	vnpuAICoreUtilizationDesc: prometheus.NewDesc(
+		"hami_container_device_utilization_ratio",
+		"Deprecated alias of hami_vnpu_aicore_utilization_ratio",
+		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
+	),
+	vnpuMemoryContextDesc: prometheus.NewDesc(
+		"hami_vgpu_memory_context_bytes",
+		"Deprecated alias of hami_vnpu_memory_context_bytes",
+		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
+	),
+	vnpuMemoryModuleDesc: prometheus.NewDesc(
+		"hami_vgpu_memory_module_bytes",
+		"Deprecated alias of hami_vnpu_memory_module_bytes",
+		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
+	),
+	vnpuMemoryBufferDesc: prometheus.NewDesc(
+		"hami_vgpu_memory_buffer_bytes",
+		"Deprecated alias of hami_vnpu_memory_buffer_bytes",
+		[]string{"namespace", "pod", "container", "vdevice_index", "device_uuid"}, nil,
+	),
+}
+
+// npuCollector exports host device telemetry and, when a containers path is
+// set, the per-container usage read from the hami-vnpu-core shmem.
+type npuCollector struct {
 	containersPath string
-	lister         *ContainerLister
+	// lister is nil when no containers path is set.
+	lister *ContainerLister
+	// legacyGPUNames also emits the legacyGPUDescs metrics.
+	legacyGPUNames bool
+	// hostTelemetry reads the host devices through DCMI. It is off for
+	// simulated NPUs, which have no DCMI behind them.
+	hostTelemetry bool
 }
 
-func newVNPUCollector(containersPath string) (*vNPUCollector, error) {
-	lister, err := NewContainerLister(containersPath)
+func newNPUCollector(cfg MetricsServerConfig, podResources manager.PodResourcesLister) (*npuCollector, error) {
+	c := &npuCollector{
+		containersPath: cfg.ContainersPath,
+		legacyGPUNames: cfg.LegacyGPUNames,
+		hostTelemetry:  !cfg.DisableHostTelemetry,
+	}
+	if cfg.ContainersPath == "" {
+		return c, nil
+	}
+	lister, err := NewContainerLister(cfg.ContainersPath, podResources)
 	if err != nil {
 		return nil, fmt.Errorf("new container lister: %w", err)
 	}
-	return &vNPUCollector{
-		containersPath: containersPath,
-		lister:         lister,
-	}, nil
+	c.lister = lister
+	return c, nil
 }
 
-func (c *vNPUCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- hostGPUdesc
-	ch <- hostGPUUtilizationdesc
-	ch <- ctrvGPUdesc
-	ch <- ctrvGPUlimitdesc
-	ch <- ctrDeviceUtilizationdesc
-	ch <- ctrDeviceMemoryContextDesc
-	ch <- ctrDeviceMemoryModuleDesc
-	ch <- ctrDeviceMemoryBufferDesc
+func (c *npuCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- npuMemoryUsedDesc
+	ch <- npuAICoreUtilizationDesc
+	ch <- npuTemperatureDesc
+	ch <- npuPowerDesc
+	ch <- npuHBMTotalDesc
+	ch <- npuHBMUsedDesc
+	ch <- npuHBMBandwidthDesc
+	ch <- npuAICPUUtilizationDesc
+	ch <- npuVectorCoreUtilizationDesc
+	ch <- npuECCErrorsDesc
+	ch <- npuECCIsolatedPagesDesc
+	ch <- npuHealthDesc
+	ch <- vnpuMemoryUsedDesc
+	ch <- vnpuMemoryLimitDesc
+	ch <- vnpuAICoreUtilizationDesc
+	ch <- vnpuMemoryContextDesc
+	ch <- vnpuMemoryModuleDesc
+	ch <- vnpuMemoryBufferDesc
+	ch <- processMemoryDesc
+	ch <- ctrComputePriorityDesc
+	ch <- workerCoreUtilizationDesc
+	ch <- workerThrottledDesc
+	ch <- workerReportTimestampDesc
+	if c.legacyGPUNames {
+		for _, legacy := range legacyGPUDescs {
+			ch <- legacy
+		}
+	}
 }
 
-func (c *vNPUCollector) Collect(ch chan<- prometheus.Metric) {
-	klog.V(4).Info("Collecting vNPU metrics")
+// sendGauge emits a gauge of desc and, when legacy names are enabled, of its
+// GPU-named alias.
+func (c *npuCollector) sendGauge(ch chan<- prometheus.Metric, desc *prometheus.Desc, value float64, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	if legacy, ok := legacyGPUDescs[desc]; ok && c.legacyGPUNames {
+		ch <- prometheus.MustNewConstMetric(legacy, prometheus.GaugeValue, value, labels...)
+	}
+}
 
-	hostDevices, err := collectHostDeviceStats()
-	if err != nil {
-		klog.Errorf("Host device stats: %v", err)
+func (c *npuCollector) Collect(ch chan<- prometheus.Metric) {
+	klog.V(4).Info("Collecting NPU metrics")
+
+	var hostDevices []DeviceStat
+	if c.hostTelemetry {
+		var err error
+		if hostDevices, err = collectHostDeviceStats(); err != nil {
+			klog.Errorf("Host device stats: %v", err)
+		}
 	}
 
-	podMemByDevice := c.collectPodMetrics(ch, hostDevices)
+	var podMemByDevice map[string]uint64
+	if c.lister != nil {
+		podMemByDevice = c.collectPodMetrics(ch, hostDevices)
+	}
 	c.collectHostMetrics(ch, hostDevices, podMemByDevice)
 }
 
@@ -105,7 +298,7 @@ func formatDeviceType(deviceType string) string {
 	return "Ascend-" + deviceType
 }
 
-func (c *vNPUCollector) collectHostMetrics(ch chan<- prometheus.Metric, devices []DeviceStat, podMemByDevice map[string]uint64) {
+func (c *npuCollector) collectHostMetrics(ch chan<- prometheus.Metric, devices []DeviceStat, podMemByDevice map[string]uint64) {
 	for _, d := range devices {
 		hostUsed := float64(d.MemoryUsed) * 1024 * 1024
 		// Override with pod aggregate if available (more accurate in device-share mode)
@@ -113,12 +306,45 @@ func (c *vNPUCollector) collectHostMetrics(ch chan<- prometheus.Metric, devices
 			hostUsed = float64(sum)
 		}
 		labels := []string{fmt.Sprint(d.Index), d.UUID, formatDeviceType(d.DeviceType)}
-		ch <- prometheus.MustNewConstMetric(hostGPUdesc, prometheus.GaugeValue, hostUsed, labels...)
-		ch <- prometheus.MustNewConstMetric(hostGPUUtilizationdesc, prometheus.GaugeValue, float64(d.AICorePct), labels...)
+		c.sendGauge(ch, npuMemoryUsedDesc, hostUsed, labels...)
+		c.sendGauge(ch, npuAICoreUtilizationDesc, float64(d.AICorePct), labels...)
+		c.collectTelemetryMetrics(ch, d, labels)
 	}
 }
 
-func (c *vNPUCollector) collectPodMetrics(ch chan<- prometheus.Metric, devices []DeviceStat) map[string]uint64 {
+// collectTelemetryMetrics exports the optional device telemetry the driver
+// reported for d.
+func (c *npuCollector) collectTelemetryMetrics(ch chan<- prometheus.Metric, d DeviceStat, labels []string) {
+	if d.Temperature != nil {
+		ch <- prometheus.MustNewConstMetric(npuTemperatureDesc, prometheus.GaugeValue, float64(*d.Temperature), labels...)
+	}
+	if d.PowerWatts != nil {
+		ch <- prometheus.MustNewConstMetric(npuPowerDesc, prometheus.GaugeValue, float64(*d.PowerWatts), labels...)
+	}
+	if d.AICPUPct != nil {
+		ch <- prometheus.MustNewConstMetric(npuAICPUUtilizationDesc, prometheus.GaugeValue, float64(*d.AICPUPct), labels...)
+	}
+	if d.VectorCorePct != nil {
+		ch <- prometheus.MustNewConstMetric(npuVectorCoreUtilizationDesc, prometheus.GaugeValue, float64(*d.VectorCorePct), labels...)
+	}
+	if d.HBM != nil {
+		// dcmi reports HBM sizes in MB.
+		ch <- prometheus.MustNewConstMetric(npuHBMTotalDesc, prometheus.GaugeValue, float64(d.HBM.MemorySize)*1024*1024, labels...)
+		ch <- prometheus.MustNewConstMetric(npuHBMUsedDesc, prometheus.GaugeValue, float64(d.HBM.Usage)*1024*1024, labels...)
+		ch <- prometheus.MustNewConstMetric(npuHBMBandwidthDesc, prometheus.GaugeValue, float64(d.HBM.BandWidthUtilRate), labels...)
+	}
+	if d.ECC != nil {
+		ch <- prometheus.MustNewConstMetric(npuECCErrorsDesc, prometheus.CounterValue, float64(d.ECC.TotalSingleBitErrorCnt), append(labels, "single_bit")...)
+		ch <- prometheus.MustNewConstMetric(npuECCErrorsDesc, prometheus.CounterValue, float64(d.ECC.TotalDoubleBitErrorCnt), append(labels, "double_bit")...)
+		ch <- prometheus.MustNewConstMetric(npuECCIsolatedPagesDesc, prometheus.GaugeValue, float64(d.ECC.SingleBitIsolatedPagesCnt), append(labels, "single_bit")...)
+		ch <- prometheus.MustNewConstMetric(npuECCIsolatedPagesDesc, prometheus.GaugeValue, float64(d.ECC.DoubleBitIsolatedPagesCnt), append(labels, "double_bit")...)
+	}
+	if d.Health != nil {
+		ch <- prometheus.MustNewConstMetric(npuHealthDesc, prometheus.GaugeValue, float64(*d.Health), labels...)
+	}
+}
+
+func (c *npuCollector) collectPodMetrics(ch chan<- prometheus.Metric, devices []DeviceStat) map[string]uint64 {
 	entries, err := c.lister.ListContainers()
 	if err != nil {
 		klog.Errorf("List containers: %v", err)
@@ -131,6 +357,7 @@ func (c *vNPUCollector) collectPodMetrics(ch chan<- prometheus.Metric, devices [
 	}
 
 	podMemByDevice := make(map[string]uint64)
+	seen := seriesSet{}
 
 	for _, e := range entries {
 		memoryLimit := e.Stats.MemoryLimit
@@ -156,17 +383,78 @@ func (c *vNPUCollector) collectPodMetrics(ch chan<- prometheus.Metric, devices [
 				devUUID,
 			}
 
-			ch <- prometheus.MustNewConstMetric(ctrvGPUdesc, prometheus.GaugeValue, float64(memoryUsed), baseLabels...)
-			ch <- prometheus.MustNewConstMetric(ctrvGPUlimitdesc, prometheus.GaugeValue, float64(memoryLimit), baseLabels...)
-			ch <- prometheus.MustNewConstMetric(ctrDeviceUtilizationdesc, prometheus.GaugeValue, utilByUUID[devUUID], baseLabels...)
-			ch <- prometheus.MustNewConstMetric(ctrDeviceMemoryContextDesc, prometheus.GaugeValue, float64(memoryContextSize), baseLabels...)
-			ch <- prometheus.MustNewConstMetric(ctrDeviceMemoryModuleDesc, prometheus.GaugeValue, float64(memoryModuleSize), baseLabels...)
-			ch <- prometheus.MustNewConstMetric(ctrDeviceMemoryBufferDesc, prometheus.GaugeValue, float64(memoryBufferSize), baseLabels...)
+			c.sendGauge(ch, vnpuMemoryUsedDesc, float64(memoryUsed), baseLabels...)
+			c.sendGauge(ch, vnpuMemoryLimitDesc, float64(memoryLimit), baseLabels...)
+			c.sendGauge(ch, vnpuAICoreUtilizationDesc, utilByUUID[devUUID], baseLabels...)
+			c.sendGauge(ch, vnpuMemoryContextDesc, float64(memoryContextSize), baseLabels...)
+			c.sendGauge(ch, vnpuMemoryModuleDesc, float64(memoryModuleSize), baseLabels...)
+			c.sendGauge(ch, vnpuMemoryBufferDesc, float64(memoryBufferSize), baseLabels...)
 
 			if devUUID != "" {
 				podMemByDevice[devUUID] += memoryUsed
 			}
 		}
+		c.collectProcessMetrics(ch, e, seen)
+		c.collectWorkerMetrics(ch, e, seen)
 	}
 	return podMemByDevice
 }
+
+// seriesSet holds the label sets of the container series sent in one scrape.
+// Two entries can resolve to the same series, e.g. the shmem of a restarted
+// container still reporting the old pid, and sending both would fail the
+// scrape with "collected before with the same name and label values".
+type seriesSet map[string]bool
+
+// add records the series of desc with the given label values and reports
+// whether it was not sent yet.
+func (s seriesSet) add(desc *prometheus.Desc, labels ...string) bool {
+	key := desc.String() + "\x00" + strings.Join(labels, "\x00")
+	if s[key] {
+		return false
+	}
+	s[key] = true
+	return true
+}
+
+// collectProcessMetrics exports the HBM usage of each active process slot on
+// each of the container's devices. Metrics are built per scrape, so the series
+// of a slot go away once it turns inactive. Series already in seen are
+// skipped.
+func (c *npuCollector) collectProcessMetrics(ch chan<- prometheus.Metric, e ContainerEntry, seen seriesSet) {
+	for _, p := range e.Processes {
+		pid := strconv.FormatUint(uint64(p.PID), 10)
+		for i, devUUID := range e.DeviceUUIDs {
+			if i >= len(p.HBMUsed) {
+				break
+			}
+			labels := []string{e.Namespace, e.PodName, e.ContainerName, pid, strconv.Itoa(i), devUUID}
+			if !seen.add(processMemoryDesc, labels...) {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(processMemoryDesc, prometheus.GaugeValue, float64(p.HBMUsed[i]), labels...)
+		}
+	}
+}
+
+// collectWorkerMetrics exports the compute priority and worker reports of a
+// container's shmem. Series already in seen are skipped.
+func (c *npuCollector) collectWorkerMetrics(ch chan<- prometheus.Metric, e ContainerEntry, seen seriesSet) {
+	if seen.add(ctrComputePriorityDesc, e.Namespace, e.PodName, e.ContainerName) {
+		ch <- prometheus.MustNewConstMetric(ctrComputePriorityDesc, prometheus.GaugeValue, float64(e.Stats.ComputePriority),
+			e.Namespace, e.PodName, e.ContainerName)
+	}
+	for _, r := range e.Stats.Reports {
+		devUUID := ""
+		if int(r.Device) < len(e.DeviceUUIDs) {
+			devUUID = e.DeviceUUIDs[r.Device]
+		}
+		labels := []string{e.Namespace, e.PodName, e.ContainerName, strconv.FormatUint(uint64(r.PID), 10), strconv.FormatUint(uint64(r.Device), 10), devUUID}
+		if !seen.add(workerCoreUtilizationDesc, labels...) {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(workerCoreUtilizationDesc, prometheus.GaugeValue, float64(r.CoreUtil), labels...)
+		ch <- prometheus.MustNewConstMetric(workerThrottledDesc, prometheus.CounterValue, float64(r.ThrottledNs)/1e9, labels...)
+		ch <- prometheus.MustNewConstMetric(workerReportTimestampDesc, prometheus.GaugeValue, float64(r.UpdatedAt), labels...)
+	}
+}
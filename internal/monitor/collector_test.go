@@ -0,0 +1,346 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// collectFunc turns one of the npuCollector's collect steps into a
+// prometheus.Collector, so it can be checked without DCMI or a pod lister.
+type collectFunc func(ch chan<- prometheus.Metric)
+
+func (f collectFunc) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(f, ch)
+}
+
+func (f collectFunc) Collect(ch chan<- prometheus.Metric) {
+	f(ch)
+}
+
+// ============================================================
+// Worker metrics
+// ============================================================
+
+func TestCollectWorkerMetrics(t *testing.T) {
+	stats := newShmemBuilder(shmemLayouts[0]).
+		u32(localShmComputePrioOffset, 2).
+		report(0, WorkerReport{PID: 100, Device: 1, CoreUtil: 40, ThrottledNs: 1_500_000_000, UpdatedAt: 1_700_000_000}).
+		report(3, WorkerReport{PID: 101, Device: 5, CoreUtil: 10, UpdatedAt: 1_700_000_001}).
+		open(t).ReadPodStats()
+	entry := ContainerEntry{
+		ContainerName: "main",
+		Namespace:     "default",
+		PodName:       "train",
+		Stats:         stats,
+		DeviceUUIDs:   []string{"npu-a", "npu-b"},
+	}
+
+	c := &npuCollector{}
+	expected := `
+# HELP hami_vnpu_compute_priority Compute priority the hami-vnpu-core limiter runs the container at
+# TYPE hami_vnpu_compute_priority gauge
+hami_vnpu_compute_priority{container="main",namespace="default",pod="train"} 2
+# HELP hami_vnpu_worker_core_utilization_ratio AICore utilization (0-100) last reported by a worker process
+# TYPE hami_vnpu_worker_core_utilization_ratio gauge
+hami_vnpu_worker_core_utilization_ratio{container="main",device_uuid="npu-b",namespace="default",pid="100",pod="train",vdevice_index="1"} 40
+hami_vnpu_worker_core_utilization_ratio{container="main",device_uuid="",namespace="default",pid="101",pod="train",vdevice_index="5"} 10
+# HELP hami_vnpu_worker_throttled_seconds_total Time the limiter held a worker process back
+# TYPE hami_vnpu_worker_throttled_seconds_total counter
+hami_vnpu_worker_throttled_seconds_total{container="main",device_uuid="npu-b",namespace="default",pid="100",pod="train",vdevice_index="1"} 1.5
+hami_vnpu_worker_throttled_seconds_total{container="main",device_uuid="",namespace="default",pid="101",pod="train",vdevice_index="5"} 0
+# HELP hami_vnpu_worker_last_report_timestamp_seconds Unix time of the last report of a worker process
+# TYPE hami_vnpu_worker_last_report_timestamp_seconds gauge
+hami_vnpu_worker_last_report_timestamp_seconds{container="main",device_uuid="npu-b",namespace="default",pid="100",pod="train",vdevice_index="1"} 1700000000
+hami_vnpu_worker_last_report_timestamp_seconds{container="main",device_uuid="",namespace="default",pid="101",pod="train",vdevice_index="5"} 1700000001
+`
+	collector := collectFunc(func(ch chan<- prometheus.Metric) {
+		c.collectWorkerMetrics(ch, entry, seriesSet{})
+	})
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCollectWorkerMetricsDuplicateSeries checks that two entries of the same
+// container reporting the same pid and device export the series once, from
+// the first entry, instead of failing the scrape.
+func TestCollectWorkerMetricsDuplicateSeries(t *testing.T) {
+	entry := func(stats PodStats) ContainerEntry {
+		return ContainerEntry{
+			ContainerName: "main",
+			Namespace:     "default",
+			PodName:       "train",
+			Stats:         stats,
+			DeviceUUIDs:   []string{"npu-a"},
+		}
+	}
+	first := entry(newShmemBuilder(shmemLayouts[0]).
+		u32(localShmComputePrioOffset, 1).
+		report(0, WorkerReport{PID: 100, CoreUtil: 40, UpdatedAt: 1_700_000_000}).
+		open(t).ReadPodStats())
+	second := entry(newShmemBuilder(shmemLayouts[0]).
+		u32(localShmComputePrioOffset, 2).
+		report(0, WorkerReport{PID: 100, CoreUtil: 90, UpdatedAt: 1_700_000_009}).
+		report(1, WorkerReport{PID: 101, CoreUtil: 10, UpdatedAt: 1_700_000_001}).
+		open(t).ReadPodStats())
+
+	c := &npuCollector{}
+	expected := `
+# HELP hami_vnpu_compute_priority Compute priority the hami-vnpu-core limiter runs the container at
+# TYPE hami_vnpu_compute_priority gauge
+hami_vnpu_compute_priority{container="main",namespace="default",pod="train"} 1
+# HELP hami_vnpu_worker_core_utilization_ratio AICore utilization (0-100) last reported by a worker process
+# TYPE hami_vnpu_worker_core_utilization_ratio gauge
+hami_vnpu_worker_core_utilization_ratio{container="main",device_uuid="npu-a",namespace="default",pid="100",pod="train",vdevice_index="0"} 40
+hami_vnpu_worker_core_utilization_ratio{container="main",device_uuid="npu-a",namespace="default",pid="101",pod="train",vdevice_index="0"} 10
+`
+	collector := collectFunc(func(ch chan<- prometheus.Metric) {
+		seen := seriesSet{}
+		c.collectWorkerMetrics(ch, first, seen)
+		c.collectWorkerMetrics(ch, second, seen)
+	})
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"hami_vnpu_compute_priority", "hami_vnpu_worker_core_utilization_ratio"); err != nil {
+		t.Error(err)
+	}
+}
+
+// ============================================================
+// Process metrics
+// ============================================================
+
+func TestCollectProcessMetrics(t *testing.T) {
+	const header = `
+# HELP hami_vnpu_process_memory_used_bytes HBM used by one process of the container in bytes
+# TYPE hami_vnpu_process_memory_used_bytes gauge
+`
+	tests := []struct {
+		name     string
+		shmem    *shmemBuilder
+		devices  []string
+		expected string
+	}{
+		{
+			name: "ActiveSlotsPerDevice",
+			shmem: newShmemBuilder(shmemLayouts[0]).
+				proc(0, 100, true, 1024, 2048).
+				proc(2, 101, true, 0, 4096),
+			devices: []string{"npu-a", "npu-b"},
+			expected: header + `
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-a",namespace="default",pid="100",pod="train",vdevice_index="0"} 1024
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-b",namespace="default",pid="100",pod="train",vdevice_index="1"} 2048
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-a",namespace="default",pid="101",pod="train",vdevice_index="0"} 0
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-b",namespace="default",pid="101",pod="train",vdevice_index="1"} 4096
+`,
+		},
+		{
+			// Once the limiter clears is_active, the slot's series go away
+			// even though its pid and usage are still in the shmem.
+			name: "InactiveSlotHasNoSeries",
+			shmem: newShmemBuilder(shmemLayouts[0]).
+				proc(0, 100, true, 1024).
+				proc(1, 101, false, 2048),
+			devices: []string{"npu-a"},
+			expected: header + `
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-a",namespace="default",pid="100",pod="train",vdevice_index="0"} 1024
+`,
+		},
+		{
+			// A slot left behind with the pid of an active one must not
+			// fail the scrape; the first slot wins.
+			name: "DuplicatePID",
+			shmem: newShmemBuilder(shmemLayouts[0]).
+				proc(0, 100, true, 1024).
+				proc(1, 100, true, 4096),
+			devices: []string{"npu-a"},
+			expected: header + `
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-a",namespace="default",pid="100",pod="train",vdevice_index="0"} 1024
+`,
+		},
+		{
+			name:     "NoActiveSlots",
+			shmem:    newShmemBuilder(shmemLayouts[0]).proc(0, 100, false, 1024),
+			devices:  []string{"npu-a"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := ContainerEntry{
+				ContainerName: "main",
+				Namespace:     "default",
+				PodName:       "train",
+				Processes:     tt.shmem.open(t).ReadProcesses(),
+				DeviceUUIDs:   tt.devices,
+			}
+			c := &npuCollector{}
+			collector := collectFunc(func(ch chan<- prometheus.Metric) {
+				c.collectProcessMetrics(ch, entry, seriesSet{})
+			})
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.expected), "hami_vnpu_process_memory_used_bytes"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestCollectProcessMetricsDuplicateSeries checks that two entries of the same
+// container with the same pid export its series once, from the first entry.
+func TestCollectProcessMetricsDuplicateSeries(t *testing.T) {
+	entry := func(shmem *shmemBuilder) ContainerEntry {
+		return ContainerEntry{
+			ContainerName: "main",
+			Namespace:     "default",
+			PodName:       "train",
+			Processes:     shmem.open(t).ReadProcesses(),
+			DeviceUUIDs:   []string{"npu-a", "npu-b"},
+		}
+	}
+	first := entry(newShmemBuilder(shmemLayouts[0]).proc(0, 100, true, 1024, 2048))
+	second := entry(newShmemBuilder(shmemLayouts[0]).
+		proc(0, 100, true, 8192, 8192).
+		proc(1, 101, true, 0, 512))
+
+	c := &npuCollector{}
+	expected := `
+# HELP hami_vnpu_process_memory_used_bytes HBM used by one process of the container in bytes
+# TYPE hami_vnpu_process_memory_used_bytes gauge
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-a",namespace="default",pid="100",pod="train",vdevice_index="0"} 1024
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-b",namespace="default",pid="100",pod="train",vdevice_index="1"} 2048
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-a",namespace="default",pid="101",pod="train",vdevice_index="0"} 0
+hami_vnpu_process_memory_used_bytes{container="main",device_uuid="npu-b",namespace="default",pid="101",pod="train",vdevice_index="1"} 512
+`
+	collector := collectFunc(func(ch chan<- prometheus.Metric) {
+		seen := seriesSet{}
+		c.collectProcessMetrics(ch, first, seen)
+		c.collectProcessMetrics(ch, second, seen)
+	})
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "hami_vnpu_process_memory_used_bytes"); err != nil {
+		t.Error(err)
+	}
+}
+
+// ============================================================
+// Legacy GPU names
+// ============================================================
+
+func TestSendGaugeLegacyGPUNames(t *testing.T) {
+	const native = `
+# HELP hami_npu_memory_used_bytes NPU device memory usage in bytes
+# TYPE hami_npu_memory_used_bytes gauge
+hami_npu_memory_used_bytes{device_index="0",device_type="Ascend-910B4",device_uuid="npu-a"} 1.048576e+08
+# HELP hami_npu_aicore_utilization_ratio NPU AI Core utilization ratio (0-100)
+# TYPE hami_npu_aicore_utilization_ratio gauge
+hami_npu_aicore_utilization_ratio{device_index="0",device_type="Ascend-910B4",device_uuid="npu-a"} 30
+# HELP hami_npu_temperature_celsius NPU chip temperature in degrees Celsius
+# TYPE hami_npu_temperature_celsius gauge
+hami_npu_temperature_celsius{device_index="0",device_type="Ascend-910B4",device_uuid="npu-a"} 45
+# HELP hami_vnpu_memory_limit_bytes vNPU device memory limit in bytes
+# TYPE hami_vnpu_memory_limit_bytes gauge
+hami_vnpu_memory_limit_bytes{container="main",device_uuid="npu-a",namespace="default",pod="train",vdevice_index="0"} 4096
+# HELP hami_vnpu_compute_priority Compute priority the hami-vnpu-core limiter runs the container at
+# TYPE hami_vnpu_compute_priority gauge
+hami_vnpu_compute_priority{container="main",namespace="default",pod="train"} 1
+`
+	const legacy = `
+# HELP hami_host_gpu_memory_used_bytes Deprecated alias of hami_npu_memory_used_bytes
+# TYPE hami_host_gpu_memory_used_bytes gauge
+hami_host_gpu_memory_used_bytes{device_index="0",device_type="Ascend-910B4",device_uuid="npu-a"} 1.048576e+08
+# HELP hami_host_gpu_utilization_ratio Deprecated alias of hami_npu_aicore_utilization_ratio
+# TYPE hami_host_gpu_utilization_ratio gauge
+hami_host_gpu_utilization_ratio{device_index="0",device_type="Ascend-910B4",device_uuid="npu-a"} 30
+# HELP hami_vgpu_memory_limit_bytes Deprecated alias of hami_vnpu_memory_limit_bytes
+# TYPE hami_vgpu_memory_limit_bytes gauge
+hami_vgpu_memory_limit_bytes{container="main",device_uuid="npu-a",namespace="default",pod="train",vdevice_index="0"} 4096
+`
+	// Every name either case may emit, so that a legacy series showing up
+	// when disabled fails the comparison.
+	names := []string{
+		"hami_npu_memory_used_bytes", "hami_npu_aicore_utilization_ratio", "hami_npu_temperature_celsius",
+		"hami_vnpu_memory_limit_bytes", "hami_vnpu_compute_priority",
+		"hami_host_gpu_memory_used_bytes", "hami_host_gpu_utilization_ratio", "hami_vgpu_memory_limit_bytes",
+		"hami_host_gpu_temperature_celsius", "hami_vgpu_compute_priority",
+	}
+
+	tests := []struct {
+		name           string
+		legacyGPUNames bool
+		expected       string
+	}{
+		{name: "NativeOnly", legacyGPUNames: false, expected: native},
+		{name: "WithAliases", legacyGPUNames: true, expected: native + legacy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			temperature := int32(45)
+			c := &npuCollector{legacyGPUNames: tt.legacyGPUNames}
+			collector := collectFunc(func(ch chan<- prometheus.Metric) {
+				c.collectHostMetrics(ch, []DeviceStat{{
+					Index:       0,
+					UUID:        "npu-a",
+					DeviceType:  "910B4",
+					MemoryUsed:  100,
+					AICorePct:   30,
+					Temperature: &temperature,
+				}}, nil)
+				c.sendGauge(ch, vnpuMemoryLimitDesc, 4096, "default", "train", "main", "0", "npu-a")
+				// No alias exists for the compute priority.
+				c.sendGauge(ch, ctrComputePriorityDesc, 1, "default", "train", "main")
+			})
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.expected), names...); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDescribeLegacyGPUNames(t *testing.T) {
+	count := func(c *npuCollector) int {
+		ch := make(chan *prometheus.Desc, 100)
+		c.Describe(ch)
+		close(ch)
+		return len(ch)
+	}
+	native := count(&npuCollector{})
+	if got, want := count(&npuCollector{legacyGPUNames: true}), native+len(legacyGPUDescs); got != want {
+		t.Errorf("Describe() with legacy names sent %d descs, want %d", got, want)
+	}
+}
+
+// ============================================================
+// Host telemetry
+// ============================================================
+
+func TestCollectWithoutHostTelemetry(t *testing.T) {
+	c, err := newNPUCollector(MetricsServerConfig{DisableHostTelemetry: true}, nil)
+	if err != nil {
+		t.Fatalf("newNPUCollector() error = %v", err)
+	}
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Errorf("collected %d metrics, want none", n)
+	}
+	if dcMgr != nil || dcMgrErr != nil {
+		t.Error("DCMI was opened with host telemetry disabled")
+	}
+}
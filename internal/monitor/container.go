@@ -1,10 +1,12 @@
 package monitor
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
@@ -17,6 +19,9 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
 )
 
 type ContainerInfo struct {
@@ -32,8 +37,11 @@ type ContainerEntry struct {
 	Namespace     string
 	PodName       string
 	Stats         PodStats
-	DeviceMemory  [hbmDevices]uint64
-	DeviceUUIDs   []string
+	DeviceMemory  []uint64
+	// Processes holds the active process slots, read in the same pass as
+	// DeviceMemory.
+	Processes   []ProcessMemory
+	DeviceUUIDs []string
 }
 
 type ContainerLister struct {
@@ -41,6 +49,13 @@ type ContainerLister struct {
 	mutex          sync.Mutex
 	clientset      *kubernetes.Clientset
 	nodeName       string
+	// podResources, when set, tells which containers kubelet still runs.
+	// It only reports the "<uuid>-<slot>" IDs kubelet chose, not the NPUs
+	// Allocate served, so device UUIDs always come from the pod annotations.
+	podResources manager.PodResourcesLister
+	// unknownLayouts holds the shmem files already reported as having an
+	// unknown layout, so each is logged and counted once.
+	unknownLayouts map[string]bool
 
 	informerFactory informers.SharedInformerFactory
 	podLister       corelisters.PodLister
@@ -48,7 +63,7 @@ type ContainerLister struct {
 	stopCh          chan struct{}
 }
 
-func NewContainerLister(containersPath string) (*ContainerLister, error) {
+func NewContainerLister(containersPath string, podResources manager.PodResourcesLister) (*ContainerLister, error) {
 	config, err := clientcmd.BuildConfigFromFlags("", os.Getenv("KUBECONFIG"))
 	if err != nil {
 		klog.Errorf("Failed to build kubeconfig: %v", err)
@@ -69,6 +84,8 @@ func NewContainerLister(containersPath string) (*ContainerLister, error) {
 		containersPath: containersPath,
 		clientset:      clientset,
 		nodeName:       nodeName,
+		podResources:   podResources,
+		unknownLayouts: map[string]bool{},
 		stopCh:         make(chan struct{}),
 	}
 
@@ -142,7 +159,13 @@ func (l *ContainerLister) ListContainers() ([]ContainerEntry, error) {
 		return nil, fmt.Errorf("read containers dir: %w", err)
 	}
 
+	alive := l.liveContainers()
+
 	var result []ContainerEntry
+	stillUnknown := map[string]bool{}
+	defer func() {
+		l.unknownLayouts = stillUnknown
+	}()
 	for _, dirent := range entries {
 		if !dirent.IsDir() {
 			continue
@@ -167,8 +190,22 @@ func (l *ContainerLister) ListContainers() ([]ContainerEntry, error) {
 			continue
 		}
 
+		if alive != nil && !alive[pod.Namespace+"/"+pod.Name+"/"+ctrName] {
+			klog.V(5).Infof("Skip container dir %s: kubelet no longer runs the container", name)
+			continue
+		}
+
 		shmemPath := filepath.Join(l.containersPath, name, "vnpu_local_shmem")
 		reader, err := OpenLocalShmem(shmemPath)
+		if errors.Is(err, ErrUnknownShmemLayout) {
+			stillUnknown[shmemPath] = true
+			if !l.unknownLayouts[shmemPath] {
+				l.unknownLayouts[shmemPath] = true
+				shmemUnknownLayouts.Inc()
+				klog.Warningf("Skip shmem %s of pod %s/%s, no vNPU metrics for it: %v", shmemPath, pod.Namespace, pod.Name, err)
+			}
+			continue
+		}
 		if err != nil {
 			klog.V(5).Infof("Skip shmem %s: %v", shmemPath, err)
 			continue
@@ -176,28 +213,74 @@ func (l *ContainerLister) ListContainers() ([]ContainerEntry, error) {
 
 		var devUUIDs []string
 		if key := ascendDeviceResource(pod, ctrName); key != "" {
-			if anno, ok := pod.Annotations[key]; ok {
-				var devs []struct{ UUID string }
-				if json.Unmarshal([]byte(anno), &devs) == nil {
-					for _, d := range devs {
-						if d.UUID != "" {
-							devUUIDs = append(devUUIDs, d.UUID)
-						}
-					}
-				}
-			}
+			devUUIDs = allocatedDeviceUUIDs(pod, ctrName, key)
 		}
 
+		procs := reader.ReadProcesses()
 		result = append(result, ContainerEntry{
 			PodUID:        podUID,
 			ContainerName: ctrName,
 			Namespace:     pod.Namespace,
 			PodName:       pod.Name,
 			Stats:         reader.ReadPodStats(),
-			DeviceMemory:  reader.ReadMemoryByDevice(),
+			DeviceMemory:  sumProcessMemory(procs, reader.layout.hbmDevices),
+			Processes:     procs,
 			DeviceUUIDs:   devUUIDs,
 		})
 		_ = reader.Close()
 	}
 	return result, nil
 }
+
+// liveContainers returns the "<namespace>/<pod>/<container>" of every
+// container kubelet runs. It returns nil when no pod-resources lister is set
+// or kubelet could not be queried.
+func (l *ContainerLister) liveContainers() map[string]bool {
+	if l.podResources == nil {
+		return nil
+	}
+	all, err := l.podResources.ListContainerDevices(context.Background())
+	if err != nil {
+		klog.Warningf("query kubelet pod-resources, keeping all container dirs: %v", err)
+		return nil
+	}
+	alive := make(map[string]bool, len(all))
+	for _, c := range all {
+		alive[c.Owner()] = true
+	}
+	return alive
+}
+
+// allocatedDeviceUUIDs reads the UUIDs of the NPUs a container was allocated
+// from the hami.io/<commonWord>-devices-allocated annotation of its pod, which
+// holds one ";"-separated entry per container of the pod spec, init
+// containers first.
+func allocatedDeviceUUIDs(pod *corev1.Pod, ctrName, resourceName string) []string {
+	commonWord := strings.TrimPrefix(resourceName, "huawei.com/")
+	anno, ok := pod.Annotations[fmt.Sprintf("hami.io/%s-devices-allocated", commonWord)]
+	if !ok {
+		return nil
+	}
+	ctrs := strings.Split(anno, device.OnePodMultiContainerSplitSymbol)
+	for i, c := range append(slices.Clone(pod.Spec.InitContainers), pod.Spec.Containers...) {
+		if c.Name != ctrName {
+			continue
+		}
+		if i >= len(ctrs) {
+			return nil
+		}
+		devs, err := device.DecodeContainerDevices(ctrs[i])
+		if err != nil {
+			klog.V(5).Infof("Decode devices of container %s in pod %s/%s: %v", ctrName, pod.Namespace, pod.Name, err)
+			return nil
+		}
+		var uuids []string
+		for _, d := range devs {
+			if d.UUID != "" {
+				uuids = append(uuids, d.UUID)
+			}
+		}
+		return uuids
+	}
+	return nil
+}
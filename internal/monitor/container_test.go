@@ -0,0 +1,99 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitor
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ============================================================
+// allocatedDeviceUUIDs
+// ============================================================
+
+func TestAllocatedDeviceUUIDs(t *testing.T) {
+	const annotation = "hami.io/Ascend910B4-devices-allocated"
+	pod := func(anno string, inits []string, ctrs ...string) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{annotation: anno}}}
+		for _, name := range inits {
+			p.Spec.InitContainers = append(p.Spec.InitContainers, corev1.Container{Name: name})
+		}
+		for _, name := range ctrs {
+			p.Spec.Containers = append(p.Spec.Containers, corev1.Container{Name: name})
+		}
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		pod     *corev1.Pod
+		ctrName string
+		want    []string
+	}{
+		{
+			name:    "SecondContainer",
+			pod:     pod("npu-a,Ascend910B4,1024,4:;npu-b,Ascend910B4,1024,4:npu-c,Ascend910B4,1024,4:;", nil, "main", "sidecar"),
+			ctrName: "sidecar",
+			want:    []string{"npu-b", "npu-c"},
+		},
+		{
+			// HAMi lists the init containers first.
+			name:    "AfterInitContainer",
+			pod:     pod("npu-init,Ascend910B4,1024,4:;npu-a,Ascend910B4,1024,4:;", []string{"setup"}, "main"),
+			ctrName: "main",
+			want:    []string{"npu-a"},
+		},
+		{
+			name:    "InitContainer",
+			pod:     pod("npu-init,Ascend910B4,1024,4:;npu-a,Ascend910B4,1024,4:;", []string{"setup"}, "main"),
+			ctrName: "setup",
+			want:    []string{"npu-init"},
+		},
+		{
+			name:    "ContainerWithoutDevices",
+			pod:     pod(";npu-a,Ascend910B4,1024,4:;", []string{"setup"}, "main"),
+			ctrName: "setup",
+		},
+		{
+			name:    "MissingEntry",
+			pod:     pod("npu-a,Ascend910B4,1024,4:;", []string{"setup"}, "main"),
+			ctrName: "main",
+		},
+		{
+			name:    "UnknownContainer",
+			pod:     pod("npu-a,Ascend910B4,1024,4:;", nil, "main"),
+			ctrName: "other",
+		},
+		{
+			name:    "NoAnnotation",
+			pod:     &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}}},
+			ctrName: "main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocatedDeviceUUIDs(tt.pod, tt.ctrName, "huawei.com/Ascend910B4")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allocatedDeviceUUIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,16 @@ type DeviceStat struct {
 	MemoryUsed  uint64
 	MemoryTotal uint64
 	AICorePct   uint32
+
+	// The fields below are nil when the driver does not report them for the
+	// chip, so that they are left out rather than exported as zero.
+	Temperature   *int32
+	PowerWatts    *float32
+	AICPUPct      *uint32
+	VectorCorePct *uint32
+	HBM           *common.HbmInfo
+	ECC           *common.ECCInfo
+	Health        *int32
 }
 
 var (
@@ -78,14 +88,44 @@ func collectHostDeviceStats() ([]DeviceStat, error) {
 			aicorePct = uint32(rate)
 		}
 
-		devices = append(devices, DeviceStat{
+		stat := DeviceStat{
 			Index:       int(logicID),
 			UUID:        uuid,
 			DeviceType:  pt,
 			MemoryTotal: memTotal,
 			MemoryUsed:  memUsed,
 			AICorePct:   aicorePct,
-		})
+		}
+		collectDeviceTelemetry(mgr, cardID, deviceID, &stat)
+		devices = append(devices, stat)
 	}
 	return devices, nil
 }
+
+// collectDeviceTelemetry fills the optional fields of stat. Each query fails
+// independently, e.g. on chips without HBM or vector cores.
+func collectDeviceTelemetry(mgr *dcmi.DcManager, cardID, deviceID int32, stat *DeviceStat) {
+	if temp, err := mgr.DcGetDeviceTemperature(cardID, deviceID); err == nil {
+		stat.Temperature = &temp
+	}
+	if power, err := mgr.DcGetDevicePowerInfo(cardID, deviceID); err == nil {
+		stat.PowerWatts = &power
+	}
+	if rate, err := mgr.DcGetDeviceUtilizationRate(cardID, deviceID, common.AICPU); err == nil {
+		pct := uint32(rate)
+		stat.AICPUPct = &pct
+	}
+	if rate, err := mgr.DcGetDeviceUtilizationRate(cardID, deviceID, common.VectorCore); err == nil {
+		pct := uint32(rate)
+		stat.VectorCorePct = &pct
+	}
+	if hbm, err := mgr.DcGetHbmInfo(cardID, deviceID); err == nil && hbm != nil {
+		stat.HBM = hbm
+	}
+	if ecc, err := mgr.DcGetDeviceEccInfo(cardID, deviceID, common.DcmiDeviceTypeHBM); err == nil && ecc != nil {
+		stat.ECC = ecc
+	}
+	if health, err := mgr.DcGetDeviceHealth(cardID, deviceID); err == nil {
+		stat.Health = &health
+	}
+}
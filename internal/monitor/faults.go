@@ -0,0 +1,54 @@
+package monitor
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+var (
+	deviceFaultSeverityDesc = prometheus.NewDesc(
+		"hami_ascend_device_fault_severity",
+		"Fault severity of the Ascend device (0 none, 1 minor, 2 major, 3 critical, 4 unknown)",
+		[]string{"resource_name", "device_uuid", "logic_id", "severity"}, nil,
+	)
+
+	deviceFaultCodeDesc = prometheus.NewDesc(
+		"hami_ascend_device_fault_code",
+		"Error code currently reported by the Ascend device; always 1",
+		[]string{"resource_name", "device_uuid", "logic_id", "code", "severity"}, nil,
+	)
+)
+
+// FaultCollector exports the fault state of every device of the given
+// managers.
+type FaultCollector struct {
+	mgrs []manager.Manager
+}
+
+func NewFaultCollector(mgrs []manager.Manager) *FaultCollector {
+	return &FaultCollector{mgrs: mgrs}
+}
+
+func (c *FaultCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- deviceFaultSeverityDesc
+	ch <- deviceFaultCodeDesc
+}
+
+func (c *FaultCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, mgr := range c.mgrs {
+		resourceName := mgr.ResourceName()
+		for _, dev := range mgr.GetDevices() {
+			logicID := strconv.Itoa(int(dev.LogicID))
+			severity := string(dev.FaultSeverity)
+			ch <- prometheus.MustNewConstMetric(deviceFaultSeverityDesc, prometheus.GaugeValue,
+				float64(dev.FaultSeverity.Level()), resourceName, dev.UUID, logicID, severity)
+			for _, code := range dev.FaultCodeStrings() {
+				ch <- prometheus.MustNewConstMetric(deviceFaultCodeDesc, prometheus.GaugeValue,
+					1, resourceName, dev.UUID, logicID, code, severity)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+// devicesManager serves a fixed device list; the collector needs nothing else
+// of the manager.
+type devicesManager struct {
+	manager.Manager
+	resourceName string
+	devices      []*manager.Device
+}
+
+func (m *devicesManager) ResourceName() string { return m.resourceName }
+
+func (m *devicesManager) GetDevices() []*manager.Device { return m.devices }
+
+// ============================================================
+// Fault collector
+// ============================================================
+
+func TestFaultCollector(t *testing.T) {
+	c := NewFaultCollector([]manager.Manager{&devicesManager{
+		resourceName: "huawei.com/Ascend910B4",
+		devices: []*manager.Device{
+			{UUID: "npu-a", LogicID: 0, Health: true, FaultSeverity: manager.FaultSeverityNone},
+			{UUID: "npu-b", LogicID: 1, FaultCodes: []int64{0x80E01801, 0x80C98008}, FaultSeverity: manager.FaultSeverityCritical},
+		},
+	}})
+	expected := `
+# HELP hami_ascend_device_fault_severity Fault severity of the Ascend device (0 none, 1 minor, 2 major, 3 critical, 4 unknown)
+# TYPE hami_ascend_device_fault_severity gauge
+hami_ascend_device_fault_severity{device_uuid="npu-a",logic_id="0",resource_name="huawei.com/Ascend910B4",severity="None"} 0
+hami_ascend_device_fault_severity{device_uuid="npu-b",logic_id="1",resource_name="huawei.com/Ascend910B4",severity="Critical"} 3
+# HELP hami_ascend_device_fault_code Error code currently reported by the Ascend device; always 1
+# TYPE hami_ascend_device_fault_code gauge
+hami_ascend_device_fault_code{code="0x80E01801",device_uuid="npu-b",logic_id="1",resource_name="huawei.com/Ascend910B4",severity="Critical"} 1
+hami_ascend_device_fault_code{code="0x80C98008",device_uuid="npu-b",logic_id="1",resource_name="huawei.com/Ascend910B4",severity="Critical"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
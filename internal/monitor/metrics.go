@@ -1,32 +1,164 @@
 package monitor
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+const (
+	metricsReadHeaderTimeout = 10 * time.Second
+	metricsReadTimeout       = 30 * time.Second
+	metricsWriteTimeout      = 30 * time.Second
+	metricsIdleTimeout       = 2 * time.Minute
 )
 
-// StartMetricsServer starts a Prometheus metrics HTTP server.
-// containersPath: path to the host directory containing per-container shmem dirs
-// (e.g., /usr/local/hami-vnpu-core/containers).
-func StartMetricsServer(bindAddr string, containersPath string) {
-	collector, err := newVNPUCollector(containersPath)
+// MetricsServerConfig configures the metrics HTTP server.
+type MetricsServerConfig struct {
+	// BindAddr is the address to listen on, e.g. ":9395".
+	BindAddr string
+	// ContainersPath is the host directory containing per-container shmem
+	// dirs (e.g., /usr/local/hami-vnpu-core/containers). When empty, only
+	// host device telemetry is collected, no per-container vNPU usage.
+	ContainersPath string
+	// DisableHostTelemetry skips the host device telemetry read through
+	// DCMI, for nodes whose NPUs are simulated.
+	DisableHostTelemetry bool
+	// LegacyGPUNames also emits the NPU metrics under the hami_host_gpu_* /
+	// hami_vgpu_* names of HAMi's GPU dashboards.
+	LegacyGPUNames bool
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set.
+	TLSCertFile string
+	TLSKeyFile  string
+	// ClientCAFile, when set, requires clients to present a certificate
+	// signed by one of its CAs. It needs TLS to be enabled.
+	ClientCAFile string
+}
+
+// MetricsServer serves /metrics and any extra handlers on its own mux.
+type MetricsServer struct {
+	cfg MetricsServerConfig
+	mux *http.ServeMux
+	srv *http.Server
+}
+
+// NewMetricsServer builds the metrics server. podResources maps containers to
+// devices for the per-container metrics; nil falls back to the pod annotations.
+func NewMetricsServer(cfg MetricsServerConfig, podResources manager.PodResourcesLister, collectors ...prometheus.Collector) (*MetricsServer, error) {
+	if cfg.BindAddr == "" {
+		return nil, fmt.Errorf("metrics bind address not set")
+	}
+	reg := prometheus.NewRegistry()
+	collector, err := newNPUCollector(cfg, podResources)
 	if err != nil {
-		klog.Errorf("Failed to create vNPU collector: %v", err)
-		return
+		return nil, fmt.Errorf("create NPU collector: %w", err)
+	}
+	if err := reg.Register(collector); err != nil {
+		return nil, fmt.Errorf("register NPU collector: %w", err)
+	}
+	if err := reg.Register(shmemUnknownLayouts); err != nil {
+		return nil, fmt.Errorf("register shmem layout counter: %w", err)
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return nil, fmt.Errorf("register collector: %w", err)
+		}
 	}
 
-	reg := prometheus.NewRegistry()
-	reg.MustRegister(collector)
+	tlsConfig, err := metricsTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	return &MetricsServer{
+		cfg: cfg,
+		mux: mux,
+		srv: &http.Server{
+			Addr:              cfg.BindAddr,
+			Handler:           mux,
+			TLSConfig:         tlsConfig,
+			ReadHeaderTimeout: metricsReadHeaderTimeout,
+			ReadTimeout:       metricsReadTimeout,
+			WriteTimeout:      metricsWriteTimeout,
+			IdleTimeout:       metricsIdleTimeout,
+		},
+	}, nil
+}
 
+// metricsTLSConfig returns the TLS config of the server, or nil for plain HTTP.
+func metricsTLSConfig(cfg MetricsServerConfig) (*tls.Config, error) {
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("metrics TLS needs both a certificate and a key file")
+	}
+	if cfg.TLSCertFile == "" {
+		if cfg.ClientCAFile != "" {
+			return nil, fmt.Errorf("metrics client CA file set without TLS certificate and key")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load metrics TLS key pair: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read metrics client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in metrics client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// Handle registers an extra handler on the server's mux.
+func (s *MetricsServer) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start binds the listen address and serves in the background. Bind errors
+// are returned; later serve errors are logged.
+func (s *MetricsServer) Start() error {
+	ln, err := net.Listen("tcp", s.cfg.BindAddr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.cfg.BindAddr, err)
+	}
+	if s.srv.TLSConfig != nil {
+		ln = tls.NewListener(ln, s.srv.TLSConfig)
+	}
+	klog.Infof("monitor metrics server starting on %s (tls: %t)", s.cfg.BindAddr, s.srv.TLSConfig != nil)
 	go func() {
-		klog.Infof("vNPU monitor metrics server starting on %s", bindAddr)
-		if err := http.ListenAndServe(bindAddr, nil); err != nil {
-			klog.Errorf("vNPU monitor metrics server error: %v", err)
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			klog.Errorf("monitor metrics server error: %v", err)
 		}
 	}()
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests
+// until ctx is done.
+func (s *MetricsServer) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
 }
@@ -0,0 +1,171 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and its key into dir and
+// returns their paths.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "hami-ascend-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+// ============================================================
+// metricsTLSConfig
+// ============================================================
+
+func TestMetricsTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+	notPEM := filepath.Join(dir, "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		cfg            MetricsServerConfig
+		wantTLS        bool
+		wantClientAuth tls.ClientAuthType
+		wantErr        string
+	}{
+		{
+			name: "PlainHTTP",
+			cfg:  MetricsServerConfig{},
+		},
+		{
+			name:    "CertWithoutKey",
+			cfg:     MetricsServerConfig{TLSCertFile: certFile},
+			wantErr: "both a certificate and a key",
+		},
+		{
+			name:    "KeyWithoutCert",
+			cfg:     MetricsServerConfig{TLSKeyFile: keyFile},
+			wantErr: "both a certificate and a key",
+		},
+		{
+			name:    "ClientCAWithoutTLS",
+			cfg:     MetricsServerConfig{ClientCAFile: certFile},
+			wantErr: "client CA file set without TLS",
+		},
+		{
+			name:    "MissingKeyFile",
+			cfg:     MetricsServerConfig{TLSCertFile: certFile, TLSKeyFile: filepath.Join(dir, "missing.key")},
+			wantErr: "load metrics TLS key pair",
+		},
+		{
+			name:           "TLS",
+			cfg:            MetricsServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile},
+			wantTLS:        true,
+			wantClientAuth: tls.NoClientCert,
+		},
+		{
+			name:           "TLSWithClientCA",
+			cfg:            MetricsServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile, ClientCAFile: certFile},
+			wantTLS:        true,
+			wantClientAuth: tls.RequireAndVerifyClientCert,
+		},
+		{
+			name:    "MissingClientCAFile",
+			cfg:     MetricsServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile, ClientCAFile: filepath.Join(dir, "missing.crt")},
+			wantErr: "read metrics client CA file",
+		},
+		{
+			name:    "ClientCAFileWithoutCertificate",
+			cfg:     MetricsServerConfig{TLSCertFile: certFile, TLSKeyFile: keyFile, ClientCAFile: notPEM},
+			wantErr: "no certificate found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := metricsTLSConfig(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("metricsTLSConfig() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("metricsTLSConfig() error = %v", err)
+			}
+			if (got != nil) != tt.wantTLS {
+				t.Fatalf("metricsTLSConfig() TLS = %t, want %t", got != nil, tt.wantTLS)
+			}
+			if got == nil {
+				return
+			}
+			if got.ClientAuth != tt.wantClientAuth {
+				t.Errorf("ClientAuth = %v, want %v", got.ClientAuth, tt.wantClientAuth)
+			}
+			if got.MinVersion != tls.VersionTLS12 {
+				t.Errorf("MinVersion = %x, want TLS 1.2", got.MinVersion)
+			}
+		})
+	}
+}
+
+func TestNewMetricsServerRejectsBadTLSConfig(t *testing.T) {
+	_, err := NewMetricsServer(MetricsServerConfig{BindAddr: ":0", ClientCAFile: "ca.crt"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "client CA file set without TLS") {
+		t.Fatalf("NewMetricsServer() error = %v, want the client CA error", err)
+	}
+}
@@ -1,14 +1,18 @@
 package monitor
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync/atomic"
 	"syscall"
 	"unsafe"
 )
 
 // LocalContainerShmem layout matching Rust #[repr(C)] struct in crates/limiter/src/shmem/mod.rs.
+// The fields up to the procs array are the same in every known layout; the
+// process slots depend on the layout, see shmemLayouts.
 const (
 	// Primary fields
 	localShmMemLimitOffset      = 0
@@ -16,26 +20,107 @@ const (
 	localShmComputePrioOffset   = 16
 	localShmActiveWorkersOffset = 48
 
-	// ProcessSlot: pid@0, hbm_used[8]@8, is_active@72, size 80.
-	procSlotSize      = 80
+	// WorkerReport: pid@0, device@4, core_util@8, throttled_ns@16,
+	// updated_at@24 (unix seconds), size 32. Unused entries have pid 0.
+	localShmReportsOffset = 56
+	reportSize            = 32
+	reportsMax            = 32
+	reportPID             = 0
+	reportDevice          = 4
+	reportCoreUtil        = 8
+	reportThrottledNs     = 16
+	reportUpdatedAt       = 24
+
+	// ProcessSlot: pid@0, hbm_used[NPU_DEVICE_MAX]@8, is_active after
+	// hbm_used, padded to 8 bytes.
 	procSlotPID       = 0
-	procSlotHBMOffset = 8 // [AtomicU64; NPU_DEVICE_MAX=8]
-	procSlotActive    = 72
+	procSlotHBMOffset = 8
 
 	// procs array starts after reports (32 * 32 = 1024) at offset 56
-	localShmProcsOffset = 1080 // 56 + 32*32
-	procsMax            = 64
-	hbmDevices          = 8
+	localShmProcsOffset = localShmReportsOffset + reportsMax*reportSize
 )
 
+// shmemLayout is one version of LocalContainerShmem.
+type shmemLayout struct {
+	name string
+	// hbmDevices is NPU_DEVICE_MAX, the number of NPUs a container can use.
+	hbmDevices int
+	procsMax   int
+}
+
+func (l shmemLayout) procSlotSize() int {
+	return procSlotHBMOffset + l.hbmDevices*8 + 8
+}
+
+func (l shmemLayout) procSlotActive() int {
+	return procSlotHBMOffset + l.hbmDevices*8
+}
+
+func (l shmemLayout) size() int {
+	return localShmProcsOffset + l.procsMax*l.procSlotSize()
+}
+
+// shmemLayouts are the known layouts. The struct has no version header, so
+// they are told apart by the file size, which libvnpu may round up to a page.
+// Add a layout here only together with the hami-vnpu-core release that
+// changed LocalContainerShmem, and its golden file in testdata.
+//
+// Only v1 is supported so far. Builds of hami-vnpu-core with a larger
+// NPU_DEVICE_MAX, e.g. for nodes with 16 NPUs, write a bigger struct that is
+// not defined here yet, so their containers are skipped as unsupported and
+// export no vNPU metrics.
+var shmemLayouts = []shmemLayout{
+	// v1 is LocalContainerShmem of hami-vnpu-core
+	// crates/limiter/src/shmem/mod.rs with NPU_DEVICE_MAX = 8 and 64
+	// process slots; testdata/shmem_v1.golden holds its offsets.
+	{name: "v1", hbmDevices: 8, procsMax: 64},
+}
+
+// ErrUnknownShmemLayout is returned for a shmem file that matches no known
+// layout, e.g. one written by a newer libvnpu or one built for more NPUs.
+var ErrUnknownShmemLayout = errors.New("unsupported vnpu shmem layout")
+
+// detectShmemLayout returns the layout of a shmem file of the given size.
+func detectShmemLayout(size int64) (shmemLayout, error) {
+	page := int64(os.Getpagesize())
+	for _, l := range shmemLayouts {
+		want := int64(l.size())
+		if size == want || size == (want+page-1)/page*page {
+			return l, nil
+		}
+	}
+	supported := make([]string, 0, len(shmemLayouts))
+	for _, l := range shmemLayouts {
+		supported = append(supported, fmt.Sprintf("%s (%d NPUs, %d bytes)", l.name, l.hbmDevices, l.size()))
+	}
+	return shmemLayout{}, fmt.Errorf("%w: %d bytes, supported layouts: %s", ErrUnknownShmemLayout, size, strings.Join(supported, ", "))
+}
+
 type PodStats struct {
 	MemoryUsed       uint64
 	MemoryLimit      uint64
 	HasActiveWorkers bool
+	// ComputePriority is the NPU_PRIORITY the limiter runs the container at.
+	ComputePriority uint32
+	Reports         []WorkerReport
+}
+
+// WorkerReport is the latest report of one worker process of the container.
+type WorkerReport struct {
+	PID uint32
+	// Device is the index into the container's devices.
+	Device uint32
+	// CoreUtil is the AICore utilization (0-100) the worker last measured.
+	CoreUtil uint64
+	// ThrottledNs is the total time the limiter held the worker back.
+	ThrottledNs uint64
+	// UpdatedAt is the unix time in seconds of the report.
+	UpdatedAt uint64
 }
 
 type ShmemReader struct {
-	data []byte
+	data   []byte
+	layout shmemLayout
 }
 
 func OpenLocalShmem(path string) (*ShmemReader, error) {
@@ -51,15 +136,21 @@ func OpenLocalShmem(path string) (*ShmemReader, error) {
 	if err != nil {
 		return nil, err
 	}
-	if fi.Size() < int64(localShmProcsOffset+procSlotSize) {
-		return nil, fmt.Errorf("file too small: %d bytes", fi.Size())
+	layout, err := detectShmemLayout(fi.Size())
+	if err != nil {
+		return nil, err
 	}
 
 	data, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
 	if err != nil {
 		return nil, fmt.Errorf("mmap: %w", err)
 	}
-	return &ShmemReader{data: data}, nil
+	return &ShmemReader{data: data, layout: layout}, nil
+}
+
+// Layout returns the name of the detected layout version.
+func (r *ShmemReader) Layout() string {
+	return r.layout.name
 }
 
 func (r *ShmemReader) Close() error {
@@ -71,23 +162,74 @@ func (r *ShmemReader) ReadPodStats() PodStats {
 		MemoryUsed:       atomic.LoadUint64((*uint64)(unsafe.Pointer(&r.data[localShmMemUsedOffset]))),
 		MemoryLimit:      atomic.LoadUint64((*uint64)(unsafe.Pointer(&r.data[localShmMemLimitOffset]))),
 		HasActiveWorkers: atomic.LoadUint32((*uint32)(unsafe.Pointer(&r.data[localShmActiveWorkersOffset]))) != 0,
+		ComputePriority:  atomic.LoadUint32((*uint32)(unsafe.Pointer(&r.data[localShmComputePrioOffset]))),
+		Reports:          r.readReports(),
 	}
 }
 
-// ReadMemoryByDevice sums HBM usage per-device across all active process slots.
-func (r *ShmemReader) ReadMemoryByDevice() [hbmDevices]uint64 {
-	var devMem [hbmDevices]uint64
-	for i := 0; i < procsMax; i++ {
-		base := localShmProcsOffset + i*procSlotSize
-		if base+procSlotSize > len(r.data) {
+// readReports returns the reports array entries in use.
+func (r *ShmemReader) readReports() []WorkerReport {
+	var reports []WorkerReport
+	for i := 0; i < reportsMax; i++ {
+		base := localShmReportsOffset + i*reportSize
+		pid := atomic.LoadUint32((*uint32)(unsafe.Pointer(&r.data[base+reportPID])))
+		if pid == 0 {
+			continue
+		}
+		reports = append(reports, WorkerReport{
+			PID:         pid,
+			Device:      atomic.LoadUint32((*uint32)(unsafe.Pointer(&r.data[base+reportDevice]))),
+			CoreUtil:    atomic.LoadUint64((*uint64)(unsafe.Pointer(&r.data[base+reportCoreUtil]))),
+			ThrottledNs: atomic.LoadUint64((*uint64)(unsafe.Pointer(&r.data[base+reportThrottledNs]))),
+			UpdatedAt:   atomic.LoadUint64((*uint64)(unsafe.Pointer(&r.data[base+reportUpdatedAt]))),
+		})
+	}
+	return reports
+}
+
+// ProcessMemory is the HBM usage of one active process slot.
+type ProcessMemory struct {
+	PID uint32
+	// HBMUsed is indexed like the container's devices; its length is the
+	// layout's NPU_DEVICE_MAX.
+	HBMUsed []uint64
+}
+
+// ReadProcesses returns the HBM usage of every active process slot.
+func (r *ShmemReader) ReadProcesses() []ProcessMemory {
+	var procs []ProcessMemory
+	slotSize, active, devices := r.layout.procSlotSize(), r.layout.procSlotActive(), r.layout.hbmDevices
+	for i := 0; i < r.layout.procsMax; i++ {
+		base := localShmProcsOffset + i*slotSize
+		if base+slotSize > len(r.data) {
 			break
 		}
-		isActive := atomic.LoadUint32((*uint32)(unsafe.Pointer(&r.data[base+procSlotActive])))
+		isActive := atomic.LoadUint32((*uint32)(unsafe.Pointer(&r.data[base+active])))
 		if isActive == 0 {
 			continue
 		}
-		for d := 0; d < hbmDevices; d++ {
-			devMem[d] += atomic.LoadUint64((*uint64)(unsafe.Pointer(&r.data[base+procSlotHBMOffset+d*8])))
+		p := ProcessMemory{
+			PID:     atomic.LoadUint32((*uint32)(unsafe.Pointer(&r.data[base+procSlotPID]))),
+			HBMUsed: make([]uint64, devices),
+		}
+		for d := 0; d < devices; d++ {
+			p.HBMUsed[d] = atomic.LoadUint64((*uint64)(unsafe.Pointer(&r.data[base+procSlotHBMOffset+d*8])))
+		}
+		procs = append(procs, p)
+	}
+	return procs
+}
+
+// ReadMemoryByDevice sums HBM usage per-device across all active process slots.
+func (r *ShmemReader) ReadMemoryByDevice() []uint64 {
+	return sumProcessMemory(r.ReadProcesses(), r.layout.hbmDevices)
+}
+
+func sumProcessMemory(procs []ProcessMemory, devices int) []uint64 {
+	devMem := make([]uint64, devices)
+	for _, p := range procs {
+		for d, used := range p.HBMUsed {
+			devMem[d] += used
 		}
 	}
 	return devMem
@@ -0,0 +1,320 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// shmemBuilder writes a synthetic LocalContainerShmem the way the limiter
+// lays it out.
+type shmemBuilder struct {
+	layout shmemLayout
+	buf    []byte
+}
+
+func newShmemBuilder(l shmemLayout) *shmemBuilder {
+	return &shmemBuilder{layout: l, buf: make([]byte, l.size())}
+}
+
+func (b *shmemBuilder) u32(off int, v uint32) *shmemBuilder {
+	binary.NativeEndian.PutUint32(b.buf[off:], v)
+	return b
+}
+
+func (b *shmemBuilder) u64(off int, v uint64) *shmemBuilder {
+	binary.NativeEndian.PutUint64(b.buf[off:], v)
+	return b
+}
+
+// report fills entry i of the reports array.
+func (b *shmemBuilder) report(i int, r WorkerReport) *shmemBuilder {
+	base := localShmReportsOffset + i*reportSize
+	b.u32(base+reportPID, r.PID)
+	b.u32(base+reportDevice, r.Device)
+	b.u64(base+reportCoreUtil, r.CoreUtil)
+	b.u64(base+reportThrottledNs, r.ThrottledNs)
+	return b.u64(base+reportUpdatedAt, r.UpdatedAt)
+}
+
+// proc fills process slot i; hbm is indexed like the container's devices.
+func (b *shmemBuilder) proc(i int, pid uint32, active bool, hbm ...uint64) *shmemBuilder {
+	base := localShmProcsOffset + i*b.layout.procSlotSize()
+	b.u32(base+procSlotPID, pid)
+	for d, used := range hbm {
+		b.u64(base+procSlotHBMOffset+d*8, used)
+	}
+	if active {
+		b.u32(base+b.layout.procSlotActive(), 1)
+	}
+	return b
+}
+
+// open writes the buffer to a file and maps it like a real container shmem.
+func (b *shmemBuilder) open(t *testing.T) *ShmemReader {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vnpu_local_shmem")
+	if err := os.WriteFile(path, b.buf, 0o600); err != nil {
+		t.Fatalf("write shmem: %v", err)
+	}
+	r, err := OpenLocalShmem(path)
+	if err != nil {
+		t.Fatalf("OpenLocalShmem() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+	return r
+}
+
+// ============================================================
+// Layout golden files
+// ============================================================
+
+// renderShmemLayout prints the offsets of a layout in the format of
+// testdata/shmem_<name>.golden.
+func renderShmemLayout(l shmemLayout) string {
+	var b strings.Builder
+	for _, f := range []struct {
+		name  string
+		value int
+	}{
+		{"mem_limit", localShmMemLimitOffset},
+		{"mem_used", localShmMemUsedOffset},
+		{"compute_priority", localShmComputePrioOffset},
+		{"active_workers", localShmActiveWorkersOffset},
+		{"reports", localShmReportsOffset},
+		{"report_size", reportSize},
+		{"reports_max", reportsMax},
+		{"procs", localShmProcsOffset},
+		{"proc_slot_size", l.procSlotSize()},
+		{"proc_slot_hbm_used", procSlotHBMOffset},
+		{"proc_slot_is_active", l.procSlotActive()},
+		{"procs_max", l.procsMax},
+		{"size", l.size()},
+	} {
+		fmt.Fprintf(&b, "%s %d\n", f.name, f.value)
+	}
+	return b.String()
+}
+
+// readGolden returns the golden file without its comment lines.
+func readGolden(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// TestShmemLayoutGolden checks every known layout against the offsets of the
+// upstream struct recorded in testdata. The golden files are written by hand
+// from hami-vnpu-core, so they are never regenerated from this code.
+func TestShmemLayoutGolden(t *testing.T) {
+	for _, l := range shmemLayouts {
+		t.Run(l.name, func(t *testing.T) {
+			want := readGolden(t, filepath.Join("testdata", "shmem_"+l.name+".golden"))
+			if got := renderShmemLayout(l); got != want {
+				t.Errorf("layout %s does not match its golden file\ngot:\n%s\nwant:\n%s", l.name, got, want)
+			}
+		})
+	}
+}
+
+// ============================================================
+// detectShmemLayout
+// ============================================================
+
+func TestDetectShmemLayout(t *testing.T) {
+	page := int64(os.Getpagesize())
+	v1Size := int64(6200)
+	roundUp := func(n int64) int64 { return (n + page - 1) / page * page }
+
+	tests := []struct {
+		name       string
+		size       int64
+		wantLayout string
+	}{
+		{name: "V1Exact", size: v1Size, wantLayout: "v1"},
+		{name: "V1PageRounded", size: roundUp(v1Size), wantLayout: "v1"},
+		{name: "Empty", size: 0},
+		{name: "Truncated", size: v1Size - 1},
+		{name: "OneByteOver", size: v1Size + 1},
+		{name: "SixteenNPUs", size: roundUp(1080 + 64*144)},
+		{name: "TwoPages", size: roundUp(v1Size) + page},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := detectShmemLayout(tt.size)
+			if tt.wantLayout == "" {
+				if !errors.Is(err, ErrUnknownShmemLayout) {
+					t.Fatalf("detectShmemLayout(%d) error = %v, want ErrUnknownShmemLayout", tt.size, err)
+				}
+				// The log must tell which layouts this build can read.
+				if !strings.Contains(err.Error(), "supported layouts: v1 (8 NPUs, 6200 bytes)") {
+					t.Errorf("detectShmemLayout(%d) error = %v, want it to list the supported layouts", tt.size, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("detectShmemLayout(%d) error = %v", tt.size, err)
+			}
+			if l.name != tt.wantLayout {
+				t.Errorf("detectShmemLayout(%d) = %s, want %s", tt.size, l.name, tt.wantLayout)
+			}
+		})
+	}
+}
+
+// ============================================================
+// ReadPodStats
+// ============================================================
+
+func TestReadPodStats(t *testing.T) {
+	v1 := shmemLayouts[0]
+	tests := []struct {
+		name  string
+		shmem *shmemBuilder
+		want  PodStats
+	}{
+		{
+			name:  "Empty",
+			shmem: newShmemBuilder(v1),
+			want:  PodStats{},
+		},
+		{
+			name: "Header",
+			shmem: newShmemBuilder(v1).
+				u64(localShmMemLimitOffset, 4<<30).
+				u64(localShmMemUsedOffset, 1<<30).
+				u32(localShmComputePrioOffset, 1).
+				u32(localShmActiveWorkersOffset, 2),
+			want: PodStats{MemoryLimit: 4 << 30, MemoryUsed: 1 << 30, ComputePriority: 1, HasActiveWorkers: true},
+		},
+		{
+			// The priority is a u32 at 16; the bytes around it belong to
+			// other fields and must not leak into it.
+			name: "ComputePriorityOffset",
+			shmem: newShmemBuilder(v1).
+				u64(localShmMemUsedOffset, ^uint64(0)).
+				u32(localShmComputePrioOffset, 3).
+				u32(localShmComputePrioOffset+4, 0xffffffff),
+			want: PodStats{MemoryUsed: ^uint64(0), ComputePriority: 3},
+		},
+		{
+			name: "ReportsSkipUnusedEntries",
+			shmem: newShmemBuilder(v1).
+				report(0, WorkerReport{PID: 100, Device: 0, CoreUtil: 35, ThrottledNs: 2_000_000, UpdatedAt: 1_700_000_000}).
+				report(5, WorkerReport{PID: 0, Device: 1, CoreUtil: 99}).
+				report(7, WorkerReport{PID: 101, Device: 1, CoreUtil: 60, UpdatedAt: 1_700_000_005}).
+				report(reportsMax-1, WorkerReport{PID: 102, Device: 2}),
+			want: PodStats{Reports: []WorkerReport{
+				{PID: 100, Device: 0, CoreUtil: 35, ThrottledNs: 2_000_000, UpdatedAt: 1_700_000_000},
+				{PID: 101, Device: 1, CoreUtil: 60, UpdatedAt: 1_700_000_005},
+				{PID: 102, Device: 2},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.shmem.open(t).ReadPodStats()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadPodStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ============================================================
+// ReadProcesses
+// ============================================================
+
+func TestReadProcesses(t *testing.T) {
+	v1 := shmemLayouts[0]
+	hbm := func(used ...uint64) []uint64 {
+		return append(used, make([]uint64, v1.hbmDevices-len(used))...)
+	}
+	tests := []struct {
+		name       string
+		shmem      *shmemBuilder
+		want       []ProcessMemory
+		wantDevice []uint64
+	}{
+		{
+			name:       "NoSlots",
+			shmem:      newShmemBuilder(v1),
+			wantDevice: hbm(),
+		},
+		{
+			// An inactive slot keeps its last pid and hbm_used; it must
+			// still be skipped.
+			name:       "InactiveSlotIgnored",
+			shmem:      newShmemBuilder(v1).proc(0, 100, false, 1<<30),
+			wantDevice: hbm(),
+		},
+		{
+			name: "ActiveSlots",
+			shmem: newShmemBuilder(v1).
+				proc(0, 100, true, 1<<30, 0, 2<<30).
+				proc(1, 101, false, 8<<30).
+				proc(4, 102, true, 0, 1<<20),
+			want: []ProcessMemory{
+				{PID: 100, HBMUsed: hbm(1<<30, 0, 2<<30)},
+				{PID: 102, HBMUsed: hbm(0, 1<<20)},
+			},
+			wantDevice: hbm(1<<30, 1<<20, 2<<30),
+		},
+		{
+			name: "AllDevicesAndLastSlot",
+			shmem: newShmemBuilder(v1).
+				proc(v1.procsMax-1, 200, true, 1, 2, 3, 4, 5, 6, 7, 8),
+			want: []ProcessMemory{
+				{PID: 200, HBMUsed: hbm(1, 2, 3, 4, 5, 6, 7, 8)},
+			},
+			wantDevice: hbm(1, 2, 3, 4, 5, 6, 7, 8),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.shmem.open(t)
+			if got := r.ReadProcesses(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadProcesses() = %+v, want %+v", got, tt.want)
+			}
+			if got := r.ReadMemoryByDevice(); !reflect.DeepEqual(got, tt.wantDevice) {
+				t.Errorf("ReadMemoryByDevice() = %v, want %v", got, tt.wantDevice)
+			}
+		})
+	}
+}
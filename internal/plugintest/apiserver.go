@@ -0,0 +1,141 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugintest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/ascend"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// APIServer is a fake Kubernetes API holding the node of the plugin and the
+// pods scheduled to it. It is installed as the HAMi client the plugin uses.
+type APIServer struct {
+	Client *fake.Clientset
+	// NodeName is the name of the node the plugin runs on.
+	NodeName string
+}
+
+// NewAPIServer creates a fake API with the node nodeName and installs it as
+// the HAMi client until the test ends.
+func NewAPIServer(t testing.TB, nodeName string) *APIServer {
+	t.Helper()
+	a := &APIServer{Client: fake.NewSimpleClientset(), NodeName: nodeName}
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	if _, err := a.Client.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create node %s: %v", nodeName, err)
+	}
+	orig := client.KubeClient
+	client.KubeClient = a.Client
+	t.Cleanup(func() { client.KubeClient = orig })
+	return a
+}
+
+// Node returns the current node.
+func (a *APIServer) Node(t testing.TB) *v1.Node {
+	t.Helper()
+	node, err := a.Client.CoreV1().Nodes().Get(context.Background(), a.NodeName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get node %s: %v", a.NodeName, err)
+	}
+	return node
+}
+
+// WaitForNodeAnnotation waits until the node has the annotation key and
+// returns its value.
+func (a *APIServer) WaitForNodeAnnotation(t testing.TB, key string) string {
+	t.Helper()
+	var value string
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, Timeout, true,
+		func(context.Context) (bool, error) {
+			var ok bool
+			value, ok = a.Node(t).Annotations[key]
+			return ok, nil
+		})
+	if err != nil {
+		t.Fatalf("node %s has no annotation %s: %v", a.NodeName, key, err)
+	}
+	return value
+}
+
+// Pod returns the current state of a pod.
+func (a *APIServer) Pod(t testing.TB, namespace, name string) *v1.Pod {
+	t.Helper()
+	pod, err := a.Client.CoreV1().Pods(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get pod %s/%s: %v", namespace, name, err)
+	}
+	return pod
+}
+
+// Assignment is what the HAMi scheduler assigned to a pod for one device
+// type: the devices of each container and the runtime info of each device.
+type Assignment struct {
+	CommonWord  string
+	Containers  device.PodSingleDevice
+	RuntimeInfo []ascend.RuntimeInfo
+}
+
+// BindPod creates pod on the node as the HAMi scheduler leaves it for the
+// device plugin: pending, annotated with its device assignment and holding
+// the node lock, so that the next Allocate serves it.
+func (a *APIServer) BindPod(t testing.TB, pod *v1.Pod, assignment Assignment) *v1.Pod {
+	t.Helper()
+	pod = pod.DeepCopy()
+	pod.Spec.NodeName = a.NodeName
+	pod.Status.Phase = v1.PodPending
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	devices := device.EncodePodSingleDevice(assignment.Containers)
+	rtInfo, err := json.Marshal(assignment.RuntimeInfo)
+	if err != nil {
+		t.Fatalf("encode runtime info: %v", err)
+	}
+	pod.Annotations[fmt.Sprintf("hami.io/%s-devices-to-allocate", assignment.CommonWord)] = devices
+	pod.Annotations[fmt.Sprintf("hami.io/%s-devices-allocated", assignment.CommonWord)] = devices
+	pod.Annotations[fmt.Sprintf("huawei.com/%s", assignment.CommonWord)] = string(rtInfo)
+	pod.Annotations[util.AssignedNodeAnnotations] = a.NodeName
+	pod.Annotations[util.BindTimeAnnotations] = fmt.Sprint(time.Now().Unix())
+	pod.Annotations[util.DeviceBindPhase] = util.DeviceBindAllocating
+
+	pod, err = a.Client.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+	node := a.Node(t)
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[nodelock.NodeLockKey] = fmt.Sprintf("%s,%s,%s", time.Now().Format(time.RFC3339), pod.Namespace, pod.Name)
+	if _, err := a.Client.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("lock node %s: %v", a.NodeName, err)
+	}
+	return pod
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugintest
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/simulated"
+)
+
+// DeviceConfig is a device config serving 910B3 chips with two templates,
+// as in the shipped hami-scheduler-device ConfigMap.
+const DeviceConfig = `
+vnpus:
+  configs:
+  - chipName: 910B3
+    commonWord: Ascend910B3
+    resourceName: huawei.com/Ascend910B3
+    resourceMemoryName: huawei.com/Ascend910B3-memory
+    memoryAllocatable: 65536
+    memoryCapacity: 65536
+    aiCore: 20
+    aiCPU: 7
+    templates:
+    - name: vir05_1c_16g
+      memory: 16384
+      aiCore: 5
+      aiCPU: 1
+    - name: vir10_3c_32g
+      memory: 32768
+      aiCore: 10
+      aiCPU: 3
+`
+
+// Backend is the Ascend driver stand-in: the simulated NPUs of a config and
+// the managers serving them.
+type Backend struct {
+	Simulated *simulated.Backend
+	Managers  []*manager.AscendManager
+}
+
+// NewBackend simulates the NPUs of cfg and returns one manager per chip name,
+// each loaded with deviceConfig.
+func NewBackend(t testing.TB, cfg simulated.Config, deviceConfig string) *Backend {
+	t.Helper()
+	sim, err := simulated.New(cfg)
+	if err != nil {
+		t.Fatalf("create simulated backend: %v", err)
+	}
+	mgrs, err := manager.NewAscendManagersFor(sim)
+	if err != nil {
+		t.Fatalf("create managers: %v", err)
+	}
+	configPath := filepath.Join(t.TempDir(), "device-config.yaml")
+	if err := os.WriteFile(configPath, []byte(deviceConfig), 0644); err != nil {
+		t.Fatalf("write device config: %v", err)
+	}
+	for _, mgr := range mgrs {
+		if err := mgr.LoadConfig(configPath); err != nil {
+			t.Fatalf("load device config for chip %s: %v", mgr.ChipName(), err)
+		}
+	}
+	return &Backend{Simulated: sim, Managers: mgrs}
+}
+
+// Chips returns the config of one card holding n 910B3 chips, with UUIDs
+// uuid-0 to uuid-<n-1>.
+func Chips(n int) simulated.Config {
+	card := simulated.Card{CardID: 0}
+	for i := 0; i < n; i++ {
+		card.Chips = append(card.Chips, simulated.Chip{
+			Name:   "910B3",
+			UUID:   "uuid-" + strconv.Itoa(i),
+			Memory: 65536,
+		})
+	}
+	return simulated.Config{Cards: []simulated.Card{card}}
+}
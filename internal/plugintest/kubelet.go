@@ -0,0 +1,232 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plugintest provides in-process stand-ins for kubelet, the
+// Kubernetes API and the Ascend driver, so that tests can run the plugin
+// servers end to end: registration, ListAndWatch, Allocate and the HAMi
+// node annotations.
+package plugintest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+// Timeout bounds how long the Wait helpers wait for the plugin.
+const Timeout = 10 * time.Second
+
+// Kubelet is a fake kubelet serving the device plugin Registration service
+// on kubelet.sock in a temp dir. Like kubelet, it connects to every plugin
+// that registers and keeps the devices of its latest ListAndWatch response.
+type Kubelet struct {
+	v1beta1.UnimplementedRegistrationServer
+
+	// Dir is the device-plugins dir holding the kubelet and plugin sockets.
+	Dir string
+
+	mu            sync.Mutex
+	server        *grpc.Server
+	registrations []*v1beta1.RegisterRequest
+	plugins       map[string]*pluginConn
+}
+
+// pluginConn is the connection to a registered plugin.
+type pluginConn struct {
+	conn    *grpc.ClientConn
+	cancel  context.CancelFunc
+	devices []*v1beta1.Device
+	updates int
+}
+
+// NewKubelet starts a fake kubelet in a temp dir and stops it when the test
+// ends.
+func NewKubelet(t testing.TB) *Kubelet {
+	t.Helper()
+	// Unix socket paths are limited to about 100 bytes, which t.TempDir()
+	// exceeds with long test names.
+	dir, err := os.MkdirTemp("", "kubelet")
+	if err != nil {
+		t.Fatalf("create kubelet dir: %v", err)
+	}
+	k := &Kubelet{Dir: dir, plugins: map[string]*pluginConn{}}
+	t.Cleanup(func() {
+		k.Stop()
+		_ = os.RemoveAll(dir)
+	})
+	if err := k.start(); err != nil {
+		t.Fatalf("start fake kubelet: %v", err)
+	}
+	return k
+}
+
+// Socket is the path of the kubelet socket.
+func (k *Kubelet) Socket() string {
+	return filepath.Join(k.Dir, filepath.Base(v1beta1.KubeletSocket))
+}
+
+func (k *Kubelet) start() error {
+	_ = os.Remove(k.Socket())
+	lis, err := net.Listen("unix", k.Socket())
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	v1beta1.RegisterRegistrationServer(server, k)
+	k.mu.Lock()
+	k.server = server
+	k.mu.Unlock()
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	return nil
+}
+
+// Stop stops the kubelet, removes its socket and drops the connections to
+// the plugins.
+func (k *Kubelet) Stop() {
+	k.mu.Lock()
+	server := k.server
+	k.server = nil
+	for name, p := range k.plugins {
+		p.cancel()
+		_ = p.conn.Close()
+		delete(k.plugins, name)
+	}
+	k.mu.Unlock()
+	if server != nil {
+		server.Stop()
+	}
+	_ = os.Remove(k.Socket())
+}
+
+// Restart stops the kubelet and starts it again on a new socket, which is
+// what the plugin watches for to register again.
+func (k *Kubelet) Restart(t testing.TB) {
+	t.Helper()
+	k.Stop()
+	if err := k.start(); err != nil {
+		t.Fatalf("restart fake kubelet: %v", err)
+	}
+}
+
+// Register records the request and starts watching the plugin's devices.
+func (k *Kubelet) Register(_ context.Context, req *v1beta1.RegisterRequest) (*v1beta1.Empty, error) {
+	if req.Version != v1beta1.Version {
+		return nil, fmt.Errorf("unsupported device plugin API version %s", req.Version)
+	}
+	conn, err := grpc.NewClient("passthrough:///"+filepath.Join(k.Dir, req.Endpoint),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connect to plugin %s: %w", req.Endpoint, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &pluginConn{conn: conn, cancel: cancel}
+
+	k.mu.Lock()
+	if old, ok := k.plugins[req.ResourceName]; ok {
+		old.cancel()
+		_ = old.conn.Close()
+	}
+	k.plugins[req.ResourceName] = p
+	k.registrations = append(k.registrations, req)
+	k.mu.Unlock()
+
+	go k.listAndWatch(ctx, p)
+	return &v1beta1.Empty{}, nil
+}
+
+// listAndWatch keeps the devices of p up to date until the stream ends.
+func (k *Kubelet) listAndWatch(ctx context.Context, p *pluginConn) {
+	stream, err := v1beta1.NewDevicePluginClient(p.conn).ListAndWatch(ctx, &v1beta1.Empty{})
+	if err != nil {
+		return
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return
+		}
+		k.mu.Lock()
+		p.devices = resp.Devices
+		p.updates++
+		k.mu.Unlock()
+	}
+}
+
+// Registrations returns the registration requests received so far.
+func (k *Kubelet) Registrations() []*v1beta1.RegisterRequest {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]*v1beta1.RegisterRequest(nil), k.registrations...)
+}
+
+// Devices returns the devices of the latest ListAndWatch response of the
+// plugin of resourceName and how many responses it has sent.
+func (k *Kubelet) Devices(resourceName string) ([]*v1beta1.Device, int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	p, ok := k.plugins[resourceName]
+	if !ok {
+		return nil, 0
+	}
+	return p.devices, p.updates
+}
+
+// WaitForDevices waits until the plugin of resourceName has sent more than
+// after ListAndWatch responses and returns the devices of the latest.
+func (k *Kubelet) WaitForDevices(t testing.TB, resourceName string, after int) []*v1beta1.Device {
+	t.Helper()
+	var devices []*v1beta1.Device
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, Timeout, true,
+		func(context.Context) (bool, error) {
+			var updates int
+			devices, updates = k.Devices(resourceName)
+			return updates > after, nil
+		})
+	if err != nil {
+		t.Fatalf("no ListAndWatch response from %s: %v", resourceName, err)
+	}
+	return devices
+}
+
+// Client returns a client of the plugin of resourceName, as kubelet uses it
+// for Allocate.
+func (k *Kubelet) Client(t testing.TB, resourceName string) v1beta1.DevicePluginClient {
+	t.Helper()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	p, ok := k.plugins[resourceName]
+	if !ok {
+		t.Fatalf("plugin %s is not registered", resourceName)
+	}
+	return v1beta1.NewDevicePluginClient(p.conn)
+}
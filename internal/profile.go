@@ -0,0 +1,200 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Placeholders that the mounts, env and preload entries of a container
+// profile may use. They are expanded per container in Allocate.
+const (
+	// PlaceholderDeviceID is the physical ID of the container's first NPU.
+	PlaceholderDeviceID = "{deviceID}"
+	// PlaceholderPodUID is the UID of the container's pod.
+	PlaceholderPodUID = "{podUID}"
+	// PlaceholderContainerName is the name of the container.
+	PlaceholderContainerName = "{containerName}"
+)
+
+// LDSoPreloadPath is where a profile's preload entries are written in the
+// container.
+const LDSoPreloadPath = "/etc/ld.so.preload"
+
+var placeholderPattern = regexp.MustCompile(`\{[A-Za-z]+\}`)
+
+// Placeholders returns the placeholders s uses, known or not.
+func Placeholders(s string) []string {
+	return placeholderPattern.FindAllString(s, -1)
+}
+
+// IsKnownPlaceholder reports whether p is one of the placeholders expanded in
+// Allocate.
+func IsKnownPlaceholder(p string) bool {
+	return p == PlaceholderDeviceID || p == PlaceholderPodUID || p == PlaceholderContainerName
+}
+
+func hasPlaceholder(s string) bool {
+	return placeholderPattern.MatchString(s)
+}
+
+// Mount is a bind mount of a host path into a container.
+type Mount struct {
+	HostPath      string `json:"hostPath"`
+	ContainerPath string `json:"containerPath"`
+	ReadOnly      bool   `json:"readOnly,omitempty"`
+}
+
+// ContainerProfile declares what the plugin gives a container besides its
+// device nodes. Fields left unset in the config fall back to those of
+// DefaultContainerProfile; an empty list or map disables them.
+type ContainerProfile struct {
+	// DriverMounts are the driver and toolchain mounts given to every
+	// container the plugin sets up itself. They cannot use placeholders, as
+	// they are also written into the CDI spec.
+	DriverMounts []Mount `json:"driverMounts,omitempty"`
+	// Mounts are the extra mounts of hami-vnpu-core containers.
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Env are the extra env vars of hami-vnpu-core containers.
+	Env map[string]string `json:"env,omitempty"`
+	// Preload are the libraries written into the /etc/ld.so.preload of
+	// hami-vnpu-core containers. When set, they replace any mount of
+	// /etc/ld.so.preload in Mounts.
+	Preload []string `json:"preload,omitempty"`
+}
+
+// DefaultContainerProfile returns the profile used for chips that configure
+// none: the Huawei driver under /usr/local/Ascend/driver, and hami-vnpu-core
+// with its preload file installed under /usr/local/hami-vnpu-core.
+func DefaultContainerProfile() ContainerProfile {
+	driverPaths := []string{
+		"/usr/local/bin/npu-smi",
+		"/etc/ascend_install.info",
+		"/usr/local/Ascend/driver/lib64/driver",
+		"/usr/local/Ascend/driver/version.info",
+	}
+	driverMounts := make([]Mount, 0, len(driverPaths))
+	for _, p := range driverPaths {
+		driverMounts = append(driverMounts, Mount{HostPath: p, ContainerPath: p, ReadOnly: true})
+	}
+	return ContainerProfile{
+		DriverMounts: driverMounts,
+		Mounts: []Mount{
+			{HostPath: "/usr/local/hami-vnpu-core", ContainerPath: "/hami-vnpu-core", ReadOnly: true},
+			// Inject the hami-vnpu-core library by overlaying the container's
+			// ld.so.preload with the one installed on the host.
+			{HostPath: "/usr/local/hami-vnpu-core/ld.so.preload", ContainerPath: LDSoPreloadPath, ReadOnly: true},
+			// Shared region of hami-vnpu-core compute partitioning.
+			{HostPath: "/usr/local/hami-shared-region", ContainerPath: "/hami-shared-region"},
+		},
+		Env: map[string]string{
+			"NPU_GLOBAL_SHM_PATH": "/hami-shared-region/" + PlaceholderDeviceID + "_global_registry",
+		},
+	}
+}
+
+// ResolveContainerProfile returns p with its unset fields taken from
+// DefaultContainerProfile. A nil p resolves to the default profile.
+func ResolveContainerProfile(p *ContainerProfile) ContainerProfile {
+	resolved := DefaultContainerProfile()
+	if p == nil {
+		return resolved
+	}
+	if p.DriverMounts != nil {
+		resolved.DriverMounts = p.DriverMounts
+	}
+	if p.Mounts != nil {
+		resolved.Mounts = p.Mounts
+	}
+	if p.Env != nil {
+		resolved.Env = p.Env
+	}
+	resolved.Preload = p.Preload
+	if len(resolved.Preload) > 0 {
+		mounts := make([]Mount, 0, len(resolved.Mounts))
+		for _, m := range resolved.Mounts {
+			if m.ContainerPath != LDSoPreloadPath {
+				mounts = append(mounts, m)
+			}
+		}
+		resolved.Mounts = mounts
+	}
+	return resolved
+}
+
+// ProfileVars are the values the placeholders of a profile expand to.
+type ProfileVars struct {
+	DeviceID      int32
+	PodUID        string
+	ContainerName string
+}
+
+// Expand returns p with its placeholders replaced by vars.
+func (p ContainerProfile) Expand(vars ProfileVars) ContainerProfile {
+	r := strings.NewReplacer(
+		PlaceholderDeviceID, strconv.Itoa(int(vars.DeviceID)),
+		PlaceholderPodUID, vars.PodUID,
+		PlaceholderContainerName, vars.ContainerName,
+	)
+	out := ContainerProfile{DriverMounts: p.DriverMounts}
+	for _, m := range p.Mounts {
+		out.Mounts = append(out.Mounts, Mount{
+			HostPath:      r.Replace(m.HostPath),
+			ContainerPath: r.Replace(m.ContainerPath),
+			ReadOnly:      m.ReadOnly,
+		})
+	}
+	if p.Env != nil {
+		out.Env = make(map[string]string, len(p.Env))
+		for k, v := range p.Env {
+			out.Env[k] = r.Replace(v)
+		}
+	}
+	for _, lib := range p.Preload {
+		out.Preload = append(out.Preload, r.Replace(lib))
+	}
+	return out
+}
+
+// Split separates the mounts and env of p that are the same for every
+// container from those using placeholders. Driver mounts are always static
+// and preload entries always per container, as they are written into a file
+// of the container.
+func (p ContainerProfile) Split() (static, perContainer ContainerProfile) {
+	static.DriverMounts = p.DriverMounts
+	perContainer.Preload = p.Preload
+	for _, m := range p.Mounts {
+		if hasPlaceholder(m.HostPath) || hasPlaceholder(m.ContainerPath) {
+			perContainer.Mounts = append(perContainer.Mounts, m)
+		} else {
+			static.Mounts = append(static.Mounts, m)
+		}
+	}
+	for k, v := range p.Env {
+		target := &static
+		if hasPlaceholder(v) {
+			target = &perContainer
+		}
+		if target.Env == nil {
+			target.Env = map[string]string{}
+		}
+		target.Env[k] = v
+	}
+	return static, perContainer
+}
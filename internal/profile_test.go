@@ -0,0 +1,127 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func containerPaths(mounts []Mount) []string {
+	paths := make([]string, 0, len(mounts))
+	for _, m := range mounts {
+		paths = append(paths, m.ContainerPath)
+	}
+	return paths
+}
+
+// ============================================================================
+// ResolveContainerProfile
+// ============================================================================
+
+func TestResolveContainerProfile(t *testing.T) {
+	def := DefaultContainerProfile()
+	driver := []Mount{{HostPath: "/opt/ascend/driver", ContainerPath: "/usr/local/Ascend/driver", ReadOnly: true}}
+
+	tests := []struct {
+		name    string
+		profile *ContainerProfile
+		want    ContainerProfile
+	}{
+		{
+			name:    "NilIsDefault",
+			profile: nil,
+			want:    def,
+		},
+		{
+			name:    "UnsetFieldsFromDefault",
+			profile: &ContainerProfile{DriverMounts: driver},
+			want:    ContainerProfile{DriverMounts: driver, Mounts: def.Mounts, Env: def.Env},
+		},
+		{
+			name:    "EmptyFieldsDisable",
+			profile: &ContainerProfile{DriverMounts: []Mount{}, Env: map[string]string{}},
+			want:    ContainerProfile{DriverMounts: []Mount{}, Mounts: def.Mounts, Env: map[string]string{}},
+		},
+		{
+			name:    "PreloadReplacesPreloadMount",
+			profile: &ContainerProfile{Preload: []string{"/hami-vnpu-core/libvnpu.so"}},
+			want: ContainerProfile{
+				DriverMounts: def.DriverMounts,
+				Mounts:       []Mount{def.Mounts[0], def.Mounts[2]},
+				Env:          def.Env,
+				Preload:      []string{"/hami-vnpu-core/libvnpu.so"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ResolveContainerProfile(tc.profile)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ResolveContainerProfile() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Expand and Split
+// ============================================================================
+
+func TestContainerProfileExpand(t *testing.T) {
+	p := ContainerProfile{
+		Mounts: []Mount{
+			{HostPath: "/var/log/npu/{podUID}/{containerName}", ContainerPath: "/var/log/npu"},
+		},
+		Env:     map[string]string{"NPU_GLOBAL_SHM_PATH": "/hami-shared-region/{deviceID}_global_registry", "FIXED": "x"},
+		Preload: []string{"/hami-vnpu-core/{deviceID}/libvnpu.so"},
+	}
+	got := p.Expand(ProfileVars{DeviceID: 3, PodUID: "uid1", ContainerName: "main"})
+
+	if got.Mounts[0].HostPath != "/var/log/npu/uid1/main" {
+		t.Errorf("HostPath = %q, want /var/log/npu/uid1/main", got.Mounts[0].HostPath)
+	}
+	wantEnv := map[string]string{"NPU_GLOBAL_SHM_PATH": "/hami-shared-region/3_global_registry", "FIXED": "x"}
+	if !reflect.DeepEqual(got.Env, wantEnv) {
+		t.Errorf("Env = %v, want %v", got.Env, wantEnv)
+	}
+	if got.Preload[0] != "/hami-vnpu-core/3/libvnpu.so" {
+		t.Errorf("Preload = %v, want [/hami-vnpu-core/3/libvnpu.so]", got.Preload)
+	}
+	// Expand must not modify the profile it is called on.
+	if p.Mounts[0].HostPath != "/var/log/npu/{podUID}/{containerName}" {
+		t.Errorf("Expand modified the profile: %q", p.Mounts[0].HostPath)
+	}
+}
+
+func TestContainerProfileSplit(t *testing.T) {
+	static, perContainer := DefaultContainerProfile().Split()
+
+	if got, want := containerPaths(static.Mounts), []string{"/hami-vnpu-core", LDSoPreloadPath, "/hami-shared-region"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("static mounts = %v, want %v", got, want)
+	}
+	if len(static.DriverMounts) != 4 || static.Env != nil {
+		t.Errorf("static = %+v, want the 4 driver mounts and no env", static)
+	}
+	if perContainer.Mounts != nil || perContainer.DriverMounts != nil {
+		t.Errorf("per-container mounts = %+v, want none", perContainer)
+	}
+	if _, ok := perContainer.Env["NPU_GLOBAL_SHM_PATH"]; !ok || len(perContainer.Env) != 1 {
+		t.Errorf("per-container env = %v, want only NPU_GLOBAL_SHM_PATH", perContainer.Env)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
@@ -13,6 +16,9 @@ import (
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/device-plugin/nvidiadevice/nvinternal/plugin"
 	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	"github.com/Project-HAMi/ascend-device-plugin/internal"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
 )
 
 var hostHookPath string
@@ -26,7 +32,21 @@ func init() {
 
 // buildContainerAllocateResponse builds the allocate response for a single container.
 func (ps *PluginServer) buildContainerAllocateResponse(pod *v1.Pod, ctrName string, containerDevs device.ContainerDevices, rtInfoLookup map[string]RuntimeInfo) (*v1beta1.ContainerAllocateResponse, error) {
+	resp, _, err := ps.buildContainerAllocation(pod, ctrName, containerDevs, rtInfoLookup)
+	return resp, err
+}
+
+// buildContainerAllocation builds the allocate response for a single container
+// together with the checkpoint record of what the container was given.
+func (ps *PluginServer) buildContainerAllocation(pod *v1.Pod, ctrName string, containerDevs device.ContainerDevices, rtInfoLookup map[string]RuntimeInfo) (*v1beta1.ContainerAllocateResponse, *ContainerAllocation, error) {
 	resp := &v1beta1.ContainerAllocateResponse{}
+	alloc := &ContainerAllocation{
+		PodUID:    pod.UID,
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		Container: ctrName,
+		Allocated: time.Now(),
+	}
 
 	var (
 		IDs            []int32
@@ -38,25 +58,30 @@ func (ps *PluginServer) buildContainerAllocateResponse(pod *v1.Pod, ctrName stri
 	for _, dev := range containerDevs {
 		d := ps.mgr.GetDeviceByUUID(dev.UUID)
 		if d == nil {
-			return nil, fmt.Errorf("unknown uuid: %s", dev.UUID)
+			return nil, nil, fmt.Errorf("unknown uuid: %s", dev.UUID)
 		}
 		IDs = append(IDs, d.PhyID)
+		allocated := AllocatedDevice{UUID: dev.UUID, PhyID: d.PhyID, Memory: int64(dev.Usedmem), Core: dev.Usedcores}
 
 		if info, ok := rtInfoLookup[dev.UUID]; ok {
 			if ascendVNPUSpec == "" && info.Temp != "" {
 				ascendVNPUSpec = info.Temp
 			}
+			allocated.Template = info.Temp
 			if info.Memory != nil {
 				memories = append(memories, info.Memory)
+				allocated.Memory = *info.Memory
 			}
 			if info.Core != nil {
 				cores = append(cores, info.Core)
+				allocated.Core = *info.Core
 			}
 		}
+		alloc.Devices = append(alloc.Devices, allocated)
 	}
 
 	if len(IDs) == 0 {
-		return nil, fmt.Errorf("annotation %s value invalid", ps.allocAnno)
+		return nil, nil, fmt.Errorf("annotation %s value invalid", ps.allocAnno)
 	}
 	ascendVisibleDevices := fmt.Sprintf("%d", IDs[0])
 	for i := 1; i < len(IDs); i++ {
@@ -68,38 +93,22 @@ func (ps *PluginServer) buildContainerAllocateResponse(pod *v1.Pod, ctrName stri
 	vnpuMode := pod.Annotations[VNPUModeAnnotation]
 	klog.V(4).Infof("Pod %s vnpu mode: %s", pod.Name, vnpuMode)
 	if vnpuMode == VNPUModeHamiCore {
-		// 1. Handle volume mount injection
-		var mounts []*v1beta1.Mount
-		// A.Huawei driver and SMI toolchain (Read-Only)
-		driverPaths := []string{
-			"/usr/local/bin/npu-smi",
-			"/etc/ascend_install.info",
-			"/usr/local/Ascend/driver/lib64/driver",
-			"/usr/local/Ascend/driver/version.info",
-		}
-		for _, p := range driverPaths {
-			mounts = append(mounts, &v1beta1.Mount{HostPath: p, ContainerPath: p, ReadOnly: true})
-		}
-
-		mounts = append(mounts, &v1beta1.Mount{
-			HostPath:      "/usr/local/hami-vnpu-core",
-			ContainerPath: "/hami-vnpu-core",
-			ReadOnly:      true,
-		})
-		// B. Inject HAMi library path by mounting /etc/ld.so.preload.
-		mounts = append(mounts, &v1beta1.Mount{
-			HostPath:      "/usr/local/hami-vnpu-core/ld.so.preload", // Template file on host
-			ContainerPath: "/etc/ld.so.preload",                      // Overwrites the target file in container
-			ReadOnly:      true,
-		})
-
-		// C. Shared directory for HAMi compute resource partitioning (Read/Write)
-		mounts = append(mounts, &v1beta1.Mount{
-			HostPath:      "/usr/local/hami-shared-region",
-			ContainerPath: "/hami-shared-region",
-			ReadOnly:      false,
-		})
-		resp.Mounts = mounts
+		profile := ps.mgr.ContainerProfile()
+		if ps.cdiSpecDir != "" {
+			// The static part of the profile comes with the CDI devices.
+			resp.CdiDevices = ps.cdiDevices(IDs, true)
+			_, profile = profile.Split()
+		} else {
+			resp.Mounts = pluginMounts(profile.DriverMounts)
+			if ps.runtimeLess {
+				resp.Devices = ascendDeviceSpecs(davinciDevices(IDs))
+			}
+		}
+		profile = profile.Expand(internal.ProfileVars{DeviceID: IDs[0], PodUID: string(pod.UID), ContainerName: ctrName})
+		resp.Mounts = append(resp.Mounts, pluginMounts(profile.Mounts)...)
+		for k, v := range profile.Env {
+			resp.Envs[k] = v
+		}
 
 		// Set NPU_MEM_QUOTA
 		if len(memories) > 0 && memories[0] != nil {
@@ -113,10 +122,6 @@ func (ps *PluginServer) buildContainerAllocateResponse(pod *v1.Pod, ctrName stri
 			klog.V(4).InfoS("Core priority set", "value", *cores[0])
 		}
 
-		// Set GLOBAL_SHM_PATH based on the first device ID.
-		resp.Envs["NPU_GLOBAL_SHM_PATH"] = fmt.Sprintf("/hami-shared-region/%d_global_registry", IDs[0])
-		klog.V(5).Infof("Create %d_global_registry", IDs[0])
-
 		// Per-container local shmem dir (like NVIDIA vgpu/containers/{podUID}_{ctrName})
 		containerShmemDir := fmt.Sprintf("%s/containers/%s_%s", hostHookPath, pod.UID, ctrName)
 		_ = os.RemoveAll(containerShmemDir)
@@ -131,14 +136,164 @@ func (ps *PluginServer) buildContainerAllocateResponse(pod *v1.Pod, ctrName stri
 			ContainerPath: "/hami-vnpu-shmem",
 			ReadOnly:      false,
 		})
-		resp.Envs["NPU_LOCAL_SHM_PATH"] = "/hami-vnpu-shmem/vnpu_local_shmem"
+		resp.Envs["NPU_LOCAL_SHM_PATH"] = hamiCoreLocalShmPath
+		if len(profile.Preload) > 0 {
+			preloadPath := filepath.Join(containerShmemDir, "ld.so.preload")
+			if err := os.WriteFile(preloadPath, []byte(strings.Join(profile.Preload, "\n")+"\n"), 0644); err != nil {
+				return nil, nil, fmt.Errorf("write ld.so.preload of %s/%s: %w", pod.UID, ctrName, err)
+			}
+			resp.Mounts = append(resp.Mounts, &v1beta1.Mount{
+				HostPath:      preloadPath,
+				ContainerPath: internal.LDSoPreloadPath,
+				ReadOnly:      true,
+			})
+		}
+		alloc.ShmemDir = containerShmemDir
 		klog.V(4).Infof("Local shmem for %s/%s: host=%s", pod.UID, ctrName, containerShmemDir)
-	} else {
-		if ascendVNPUSpec != "" {
+	} else if ascendVNPUSpec != "" {
+		// Hard-slice vNPUs are set up by the Ascend runtime even in CDI mode.
+		if ps.mgr.CreateVNPUEnabled() {
+			vnpus, err := ps.createContainerVNPUs(pod, ctrName, containerDevs, rtInfoLookup)
+			if err != nil {
+				return nil, nil, err
+			}
+			ids := make([]string, 0, len(vnpus))
+			nodes := make([]string, 0, len(vnpus))
+			for i, vnpu := range vnpus {
+				ids = append(ids, strconv.FormatUint(uint64(vnpu.VDevID), 10))
+				nodes = append(nodes, vdavinciDevice(vnpu.VDevID))
+				alloc.Devices[i].VNPU = &CheckpointVNPU{LogicID: vnpu.LogicID, VDevID: vnpu.VDevID}
+			}
+			resp.Envs["ASCEND_VISIBLE_DEVICES"] = strings.Join(ids, ",")
+			// The plugin created the vNPUs, so their device nodes can be
+			// handed out without the runtime.
+			if ps.runtimeLess {
+				resp.Devices = ascendDeviceSpecs(nodes)
+				resp.Mounts = ps.runtimeLessMounts()
+			}
+		} else if ps.runtimeLess {
+			// Only the Ascend runtime can create vNPUs from a spec, and pods
+			// of a runtime-less node do not run with it, with or without CDI:
+			// the container would start without an NPU.
+			return nil, nil, fmt.Errorf("template vNPU %s needs vnpus.createVNPU on a runtime-less node", ascendVNPUSpec)
+		} else {
+			// Only the Ascend runtime can create vNPUs from a spec.
 			resp.Envs["ASCEND_VNPU_SPECS"] = ascendVNPUSpec
 		}
+	} else if ps.cdiSpecDir != "" {
+		resp.CdiDevices = ps.cdiDevices(IDs, false)
+	} else if ps.runtimeLess {
+		resp.Devices = ascendDeviceSpecs(davinciDevices(IDs))
+		resp.Mounts = ps.runtimeLessMounts()
+	}
+	return resp, alloc, nil
+}
+
+// SetRuntimeLess makes Allocate return the NPU device nodes and driver mounts
+// itself, so that pods get their NPUs on plain runc nodes without the ascend
+// RuntimeClass. Template vNPUs are only served if the plugin creates them;
+// Allocate fails for them otherwise.
+func (ps *PluginServer) SetRuntimeLess(enabled bool) {
+	ps.runtimeLess = enabled
+}
+
+// ascendControlDevices are the device nodes every NPU container needs next
+// to its /dev/davinciN.
+var ascendControlDevices = []string{
+	"/dev/davinci_manager",
+	"/dev/devmm_svm",
+	"/dev/hisi_hdc",
+}
+
+// runtimeLessDriverMounts are the driver files ascend-docker-runtime mounts
+// besides the profile's DriverMounts, which runtime-less containers get from
+// the plugin instead.
+var runtimeLessDriverMounts = []internal.Mount{
+	{HostPath: "/usr/local/Ascend/driver/lib64/common", ContainerPath: "/usr/local/Ascend/driver/lib64/common", ReadOnly: true},
+	{HostPath: "/usr/local/dcmi", ContainerPath: "/usr/local/dcmi", ReadOnly: true},
+}
+
+// runtimeLessMounts returns the mounts of a runtime-less container: the
+// profile's DriverMounts followed by runtimeLessDriverMounts.
+func (ps *PluginServer) runtimeLessMounts() []*v1beta1.Mount {
+	return append(pluginMounts(ps.mgr.ContainerProfile().DriverMounts), pluginMounts(runtimeLessDriverMounts)...)
+}
+
+// davinciDevice returns the device node of the NPU with the given physical ID.
+func davinciDevice(phyID int32) string {
+	return fmt.Sprintf("/dev/davinci%d", phyID)
+}
+
+// davinciDevices returns the device nodes of the NPUs with the given
+// physical IDs.
+func davinciDevices(phyIDs []int32) []string {
+	nodes := make([]string, 0, len(phyIDs))
+	for _, id := range phyIDs {
+		nodes = append(nodes, davinciDevice(id))
+	}
+	return nodes
+}
+
+// vdavinciDevice returns the device node of the vNPU with the given vdev ID.
+func vdavinciDevice(vdevID uint32) string {
+	return fmt.Sprintf("/dev/vdavinci%d", vdevID)
+}
+
+// ascendDeviceSpecs returns the device specs of the given NPU device nodes
+// followed by the control devices, all mapped to the same path in the
+// container.
+func ascendDeviceSpecs(nodes []string) []*v1beta1.DeviceSpec {
+	specs := make([]*v1beta1.DeviceSpec, 0, len(nodes)+len(ascendControlDevices))
+	for _, paths := range [][]string{nodes, ascendControlDevices} {
+		for _, path := range paths {
+			specs = append(specs, &v1beta1.DeviceSpec{ContainerPath: path, HostPath: path, Permissions: "rw"})
+		}
+	}
+	return specs
+}
+
+// pluginMounts converts profile mounts to device plugin mounts.
+func pluginMounts(mounts []internal.Mount) []*v1beta1.Mount {
+	out := make([]*v1beta1.Mount, 0, len(mounts))
+	for _, m := range mounts {
+		out = append(out, &v1beta1.Mount{HostPath: m.HostPath, ContainerPath: m.ContainerPath, ReadOnly: m.ReadOnly})
+	}
+	return out
+}
+
+// hamiCoreLocalShmPath is where hami-vnpu-core finds its per-container shmem.
+const hamiCoreLocalShmPath = "/hami-vnpu-shmem/vnpu_local_shmem"
+
+// createContainerVNPUs creates one hard-slice vNPU per device of the container
+// from the template HAMi chose for it, in the order of containerDevs. If any
+// creation fails, the vNPUs created so far are destroyed again so the failure
+// surfaces at Allocate time.
+func (ps *PluginServer) createContainerVNPUs(pod *v1.Pod, ctrName string, containerDevs device.ContainerDevices, rtInfoLookup map[string]RuntimeInfo) ([]*manager.VNPU, error) {
+	owner := fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, ctrName)
+	created := make([]*manager.VNPU, 0, len(containerDevs))
+	for _, dev := range containerDevs {
+		info := rtInfoLookup[dev.UUID]
+		if info.Temp == "" {
+			ps.destroyVNPUs(created)
+			return nil, fmt.Errorf("no vNPU template for device %s", dev.UUID)
+		}
+		vnpu, err := ps.mgr.CreateVNPU(dev.UUID, info.Temp, owner)
+		if err != nil {
+			ps.destroyVNPUs(created)
+			return nil, fmt.Errorf("create vNPU for %s: %w", owner, err)
+		}
+		created = append(created, vnpu)
+	}
+	return created, nil
+}
+
+// destroyVNPUs rolls back vNPUs created for an Allocate call that failed.
+func (ps *PluginServer) destroyVNPUs(vnpus []*manager.VNPU) {
+	for _, v := range vnpus {
+		if err := ps.mgr.DestroyVNPU(v); err != nil {
+			klog.Errorf("rollback vNPU %d for %s: %v", v.VDevID, v.Owner, err)
+		}
 	}
-	return resp, nil
 }
 
 // popNextContainerDevices finds and erases the first non-empty containerDevices
@@ -214,11 +369,25 @@ func (ps *PluginServer) patchErasedAnnotation(pod *v1.Pod, podSingleDev device.P
 }
 
 // podAllocationTrySuccess checks if all containers of this pod have been
-// allocated. If so, it sets bind-phase to "success" and releases the node
-// lock; otherwise it returns without setting bind-phase or releasing the lock,
-// waiting for the next Allocate call.
-func (ps *PluginServer) podAllocationTrySuccess(pod *v1.Pod) {
-	plugin.PodAllocationTrySuccess(ps.nodeName, ps.commonWord, NodeLockAscend, pod)
+// allocated, i.e. podSingleDev as just patched holds no devices any more. If
+// so, it sets bind-phase to "success" and releases the node lock; otherwise
+// it returns without setting bind-phase or releasing the lock, waiting for
+// the next Allocate call. A failed bind-phase patch is returned, so that
+// Allocate can fail and roll back instead of leaving the pod allocating.
+func (ps *PluginServer) podAllocationTrySuccess(pod *v1.Pod, podSingleDev device.PodSingleDevice) error {
+	for _, ctrDevs := range podSingleDev {
+		if len(ctrDevs) > 0 {
+			return nil
+		}
+	}
+	klog.Infof("Pod allocation successful for pod %s/%s on node %s", pod.Namespace, pod.Name, ps.nodeName)
+	if err := util.PatchPodAnnotations(pod, map[string]string{util.DeviceBindPhase: util.DeviceBindSuccess}); err != nil {
+		return fmt.Errorf("set bind phase of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	if err := nodelock.ReleaseNodeLock(ps.nodeName, NodeLockAscend, pod, false); err != nil {
+		klog.Errorf("Failed to release node lock for node %s and lock %s: %v", ps.nodeName, NodeLockAscend, err)
+	}
+	return nil
 }
 
 // podAllocationFailed sets bind-phase to "failed" and releases the node lock.
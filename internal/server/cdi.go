@@ -0,0 +1,196 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+	"tags.cncf.io/container-device-interface/pkg/parser"
+	cdispec "tags.cncf.io/container-device-interface/specs-go"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal"
+)
+
+// CDISoftSliceDevice is the CDI device carrying the hami-vnpu-core mounts,
+// requested next to the NPUs of soft-slice containers.
+const CDISoftSliceDevice = "hami-vnpu-core"
+
+// SetCDISpecDir makes the server write a CDI spec for its devices into dir
+// on every start and config reload, and answer Allocate with CDI device names, so that
+// containerd or CRI-O inject the devices without the Ascend runtime. An empty
+// dir disables CDI.
+func (ps *PluginServer) SetCDISpecDir(dir string) {
+	ps.cdiSpecDir = dir
+}
+
+// RefreshCDISpec rewrites the server's CDI spec, so that a reloaded
+// container profile reaches the next containers without a restart. It does
+// nothing when CDI is disabled.
+func (ps *PluginServer) RefreshCDISpec() error {
+	if ps.cdiSpecDir == "" {
+		return nil
+	}
+	return ps.writeCDISpec()
+}
+
+// cdiKind is the CDI vendor/class of the server's devices, which is its
+// resource name, e.g. huawei.com/Ascend910B4.
+func (ps *PluginServer) cdiKind() string {
+	return ps.mgr.ResourceName()
+}
+
+// cdiDeviceName returns the fully qualified CDI name of the device name of
+// the server's kind.
+func (ps *PluginServer) cdiDeviceName(name string) string {
+	return ps.cdiKind() + "=" + name
+}
+
+// cdiDevices returns the CDI devices of a container holding the NPUs with
+// the given physical IDs.
+func (ps *PluginServer) cdiDevices(phyIDs []int32, softSlice bool) []*v1beta1.CDIDevice {
+	devices := make([]*v1beta1.CDIDevice, 0, len(phyIDs)+1)
+	for _, id := range phyIDs {
+		devices = append(devices, &v1beta1.CDIDevice{Name: ps.cdiDeviceName(strconv.Itoa(int(id)))})
+	}
+	if softSlice {
+		devices = append(devices, &v1beta1.CDIDevice{Name: ps.cdiDeviceName(CDISoftSliceDevice)})
+	}
+	return devices
+}
+
+// cdiMounts converts profile mounts to CDI bind mounts.
+func cdiMounts(mounts []internal.Mount) []*cdispec.Mount {
+	out := make([]*cdispec.Mount, 0, len(mounts))
+	for _, m := range mounts {
+		mode := "rw"
+		if m.ReadOnly {
+			mode = "ro"
+		}
+		out = append(out, &cdispec.Mount{
+			HostPath:      m.HostPath,
+			ContainerPath: m.ContainerPath,
+			Options:       []string{mode, "nosuid", "nodev", "bind"},
+		})
+	}
+	return out
+}
+
+// cdiSpec builds the CDI spec of the server's current devices: one device
+// per NPU named by its physical ID, plus the soft-slice device with the
+// mounts and env of the container profile that use no placeholders. The
+// control devices and the driver mounts are shared by all of them.
+func (ps *PluginServer) cdiSpec() (*cdispec.Spec, error) {
+	vendor, class := parser.ParseQualifier(ps.cdiKind())
+	if err := parser.ValidateVendorName(vendor); err != nil {
+		return nil, fmt.Errorf("resource name %s is not a valid CDI kind: %w", ps.cdiKind(), err)
+	}
+	if err := parser.ValidateClassName(class); err != nil {
+		return nil, fmt.Errorf("resource name %s is not a valid CDI kind: %w", ps.cdiKind(), err)
+	}
+
+	profile, _ := ps.mgr.ContainerProfile().Split()
+	spec := &cdispec.Spec{
+		Kind: ps.cdiKind(),
+		ContainerEdits: cdispec.ContainerEdits{
+			Mounts: cdiMounts(profile.DriverMounts),
+		},
+	}
+	for _, path := range ascendControlDevices {
+		spec.ContainerEdits.DeviceNodes = append(spec.ContainerEdits.DeviceNodes, &cdispec.DeviceNode{Path: path})
+	}
+	for _, dev := range ps.mgr.GetDevices() {
+		spec.Devices = append(spec.Devices, cdispec.Device{
+			Name: strconv.Itoa(int(dev.PhyID)),
+			ContainerEdits: cdispec.ContainerEdits{
+				DeviceNodes: []*cdispec.DeviceNode{{Path: davinciDevice(dev.PhyID)}},
+			},
+		})
+	}
+	env := []string{"NPU_LOCAL_SHM_PATH=" + hamiCoreLocalShmPath}
+	for k, v := range profile.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env[1:])
+	spec.Devices = append(spec.Devices, cdispec.Device{
+		Name: CDISoftSliceDevice,
+		ContainerEdits: cdispec.ContainerEdits{
+			Mounts: cdiMounts(profile.Mounts),
+			Env:    env,
+		},
+	})
+
+	version, err := cdispec.MinimumRequiredVersion(spec)
+	if err != nil {
+		return nil, fmt.Errorf("get CDI spec version: %w", err)
+	}
+	spec.Version = version
+	return spec, nil
+}
+
+// cdiSpecPath is where the server's spec is written, named <vendor>-<class>
+// as CDI suggests.
+func (ps *PluginServer) cdiSpecPath() string {
+	return filepath.Join(ps.cdiSpecDir, strings.ReplaceAll(ps.cdiKind(), "/", "-")+".json")
+}
+
+// writeCDISpec writes the server's CDI spec through a temp file and a rename,
+// so runtimes never read a partial spec.
+func (ps *PluginServer) writeCDISpec() error {
+	spec, err := ps.cdiSpec()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode CDI spec: %w", err)
+	}
+	if err := os.MkdirAll(ps.cdiSpecDir, 0755); err != nil {
+		return fmt.Errorf("create CDI spec dir: %w", err)
+	}
+	specPath := ps.cdiSpecPath()
+	tmp, err := os.CreateTemp(ps.cdiSpecDir, "."+filepath.Base(specPath)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create CDI spec temp file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write CDI spec temp file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("chmod CDI spec temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close CDI spec temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), specPath); err != nil {
+		return fmt.Errorf("rename CDI spec: %w", err)
+	}
+	klog.Infof("wrote CDI spec %s with %d devices", specPath, len(spec.Devices))
+	return nil
+}
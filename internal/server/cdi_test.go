@@ -0,0 +1,195 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	cdispec "tags.cncf.io/container-device-interface/specs-go"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+func newCDITestServer(resourceName, specDir string) *PluginServer {
+	return &PluginServer{
+		mgr: &FakeManager{
+			ResourceNameFunc: func() string { return resourceName },
+			GetDevicesFunc: func() []*manager.Device {
+				return []*manager.Device{{UUID: "uuid0", PhyID: 0}, {UUID: "uuid5", PhyID: 5}}
+			},
+		},
+		cdiSpecDir: specDir,
+	}
+}
+
+// ============================================================================
+// cdiSpec tests
+// ============================================================================
+
+func TestCDISpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		resourceName string
+		wantDevices  []string
+		wantErr      bool
+	}{
+		{
+			name:         "DevicesByPhyID",
+			resourceName: "huawei.com/Ascend910B4",
+			wantDevices:  []string{"0", "5", CDISoftSliceDevice},
+		},
+		{
+			name:         "ResourceNameWithoutVendor",
+			resourceName: "Ascend910B4",
+			wantErr:      true,
+		},
+		{
+			name:         "InvalidClass",
+			resourceName: "huawei.com/Ascend 910",
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			spec, err := newCDITestServer(tc.resourceName, "").cdiSpec()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("cdiSpec() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if spec.Kind != tc.resourceName {
+				t.Errorf("Kind = %q, want %q", spec.Kind, tc.resourceName)
+			}
+			if spec.Version == "" {
+				t.Error("Version is empty")
+			}
+			var names []string
+			for _, d := range spec.Devices {
+				names = append(names, d.Name)
+			}
+			if !reflect.DeepEqual(names, tc.wantDevices) {
+				t.Errorf("devices = %v, want %v", names, tc.wantDevices)
+			}
+			if got := spec.Devices[1].ContainerEdits.DeviceNodes[0].Path; got != "/dev/davinci5" {
+				t.Errorf("device 5 node = %q, want /dev/davinci5", got)
+			}
+			if len(spec.ContainerEdits.DeviceNodes) != len(ascendControlDevices) {
+				t.Errorf("spec has %d control devices, want %d", len(spec.ContainerEdits.DeviceNodes), len(ascendControlDevices))
+			}
+		})
+	}
+}
+
+// ============================================================================
+// writeCDISpec tests
+// ============================================================================
+
+func TestWriteCDISpec(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "cdi")
+	ps := newCDITestServer("huawei.com/Ascend910B4", dir)
+	if err := ps.writeCDISpec(); err != nil {
+		t.Fatalf("writeCDISpec() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read spec dir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "huawei.com-Ascend910B4.json" {
+		t.Fatalf("spec dir holds %v, want only huawei.com-Ascend910B4.json", entries)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatalf("read spec: %v", err)
+	}
+	var spec cdispec.Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if spec.Kind != "huawei.com/Ascend910B4" || len(spec.Devices) != 3 {
+		t.Errorf("spec kind %q with %d devices, want huawei.com/Ascend910B4 with 3", spec.Kind, len(spec.Devices))
+	}
+
+	// A second write replaces the spec in place.
+	if err := ps.writeCDISpec(); err != nil {
+		t.Fatalf("second writeCDISpec() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("spec dir holds %d files after rewrite, want 1", len(entries))
+	}
+}
+
+// ============================================================================
+// RefreshCDISpec tests
+// ============================================================================
+
+func TestRefreshCDISpec(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Disabled", func(t *testing.T) {
+		ps := newCDITestServer("huawei.com/Ascend910B4", "")
+		if err := ps.RefreshCDISpec(); err != nil {
+			t.Fatalf("RefreshCDISpec() error = %v", err)
+		}
+	})
+
+	t.Run("ProfileChange", func(t *testing.T) {
+		dir := t.TempDir()
+		ps := newCDITestServer("huawei.com/Ascend910B4", dir)
+		profile := internal.DefaultContainerProfile()
+		ps.mgr.(*FakeManager).ContainerProfileFunc = func() internal.ContainerProfile { return profile }
+		if err := ps.writeCDISpec(); err != nil {
+			t.Fatalf("writeCDISpec() error = %v", err)
+		}
+
+		// A reloaded profile must reach the soft-slice device without a
+		// restart of the server.
+		profile.Env = map[string]string{"FOO": "bar"}
+		if err := ps.RefreshCDISpec(); err != nil {
+			t.Fatalf("RefreshCDISpec() error = %v", err)
+		}
+		data, err := os.ReadFile(ps.cdiSpecPath())
+		if err != nil {
+			t.Fatalf("read spec: %v", err)
+		}
+		var spec cdispec.Spec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			t.Fatalf("decode spec: %v", err)
+		}
+		var env []string
+		for _, dev := range spec.Devices {
+			if dev.Name == CDISoftSliceDevice {
+				env = dev.ContainerEdits.Env
+			}
+		}
+		if want := []string{"NPU_LOCAL_SHM_PATH=" + hamiCoreLocalShmPath, "FOO=bar"}; !reflect.DeepEqual(env, want) {
+			t.Errorf("soft-slice device env = %v, want %v", env, want)
+		}
+	})
+}
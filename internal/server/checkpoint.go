@@ -0,0 +1,287 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/HAMi/pkg/util/client"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+// CheckpointVNPU identifies a hard-slice vNPU the plugin created for a container.
+type CheckpointVNPU struct {
+	LogicID int32  `json:"logicID"`
+	VDevID  uint32 `json:"vdevID"`
+}
+
+// AllocatedDevice is one device handed to a container, with its quotas.
+type AllocatedDevice struct {
+	UUID     string          `json:"uuid"`
+	PhyID    int32           `json:"phyID"`
+	Memory   int64           `json:"memory,omitempty"`
+	Core     int32           `json:"core,omitempty"`
+	Template string          `json:"template,omitempty"`
+	VNPU     *CheckpointVNPU `json:"vnpu,omitempty"`
+}
+
+// ContainerAllocation records what a successful Allocate gave one container.
+type ContainerAllocation struct {
+	PodUID    types.UID         `json:"podUID"`
+	Namespace string            `json:"namespace"`
+	Pod       string            `json:"pod"`
+	Container string            `json:"container"`
+	Devices   []AllocatedDevice `json:"devices"`
+	// ShmemDir is the host dir of the container's hami-vnpu-core local shmem.
+	ShmemDir  string    `json:"shmemDir,omitempty"`
+	Allocated time.Time `json:"allocated"`
+}
+
+type checkpointData struct {
+	ResourceName string                `json:"resourceName"`
+	Allocations  []ContainerAllocation `json:"allocations"`
+}
+
+// allocationCheckpoint persists the allocations of one PluginServer so that
+// they survive plugin restarts.
+type allocationCheckpoint struct {
+	mu           sync.Mutex
+	path         string
+	resourceName string
+	allocations  []ContainerAllocation
+}
+
+func newAllocationCheckpoint(path, resourceName string) *allocationCheckpoint {
+	return &allocationCheckpoint{path: path, resourceName: resourceName}
+}
+
+// load reads the checkpoint file. A missing file is an empty checkpoint.
+func (c *allocationCheckpoint) load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		c.allocations = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read checkpoint %s: %w", c.path, err)
+	}
+	var cp checkpointData
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return fmt.Errorf("decode checkpoint %s: %w", c.path, err)
+	}
+	c.allocations = cp.Allocations
+	return nil
+}
+
+// save writes the checkpoint through a temp file and a rename, so a crash
+// never leaves a truncated file behind. Callers hold c.mu.
+func (c *allocationCheckpoint) save() error {
+	data, err := json.Marshal(checkpointData{ResourceName: c.resourceName, Allocations: c.allocations})
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create checkpoint temp file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write checkpoint temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close checkpoint temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("rename checkpoint: %w", err)
+	}
+	return nil
+}
+
+// add records allocations, replacing earlier records of the same container,
+// and saves the checkpoint.
+func (c *allocationCheckpoint) add(allocs ...ContainerAllocation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, a := range allocs {
+		replaced := false
+		for i, old := range c.allocations {
+			if old.PodUID == a.PodUID && old.Container == a.Container {
+				c.allocations[i] = a
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			c.allocations = append(c.allocations, a)
+		}
+	}
+	return c.save()
+}
+
+// list returns a copy of the recorded allocations.
+func (c *allocationCheckpoint) list() []ContainerAllocation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ContainerAllocation(nil), c.allocations...)
+}
+
+// retain keeps the allocations for which keep returns true, saves the
+// checkpoint if anything was dropped and returns the dropped allocations.
+func (c *allocationCheckpoint) retain(keep func(ContainerAllocation) bool) ([]ContainerAllocation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var kept, dropped []ContainerAllocation
+	for _, a := range c.allocations {
+		if keep(a) {
+			kept = append(kept, a)
+		} else {
+			dropped = append(dropped, a)
+		}
+	}
+	if len(dropped) == 0 {
+		return nil, nil
+	}
+	c.allocations = kept
+	return dropped, c.save()
+}
+
+// recordAllocations adds the allocations of a successful Allocate to the
+// checkpoint. A failed write is logged only: the allocation itself is valid.
+func (ps *PluginServer) recordAllocations(allocs []ContainerAllocation) {
+	if ps.checkpoint == nil || len(allocs) == 0 {
+		return
+	}
+	if err := ps.checkpoint.add(allocs...); err != nil {
+		klog.Errorf("record allocations in checkpoint: %v", err)
+	}
+}
+
+// livePodUIDs returns the UIDs of the pods on the node that have not
+// terminated.
+func (ps *PluginServer) livePodUIDs(ctx context.Context) (map[types.UID]bool, error) {
+	kubeClient := client.GetClient()
+	if kubeClient == nil {
+		return nil, fmt.Errorf("kube client not initialized")
+	}
+	pods, err := kubeClient.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + ps.nodeName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods on node %s: %w", ps.nodeName, err)
+	}
+	live := make(map[types.UID]bool, len(pods.Items))
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		live[pod.UID] = true
+	}
+	return live, nil
+}
+
+// vnpuOf returns the vNPU a checkpointed device was served from, or nil for
+// devices served without a plugin-created vNPU.
+func vnpuOf(a ContainerAllocation, d AllocatedDevice) *manager.VNPU {
+	if d.VNPU == nil {
+		return nil
+	}
+	return &manager.VNPU{
+		UUID:     d.UUID,
+		LogicID:  d.VNPU.LogicID,
+		VDevID:   d.VNPU.VDevID,
+		Template: d.Template,
+		Owner:    a.Namespace + "/" + a.Pod + "/" + a.Container,
+		Created:  a.Allocated,
+	}
+}
+
+// reconcileCheckpoint loads the checkpoint, drops the allocations of pods
+// that no longer run on the node and hands the vNPUs of the others back to
+// the manager. It runs before the first idle vNPU cleanup of a Start, so that
+// a restart does not destroy the vNPUs of containers still starting. When the
+// pods cannot be listed, all recorded vNPUs are restored.
+func (ps *PluginServer) reconcileCheckpoint(ctx context.Context) error {
+	if ps.checkpoint == nil {
+		return nil
+	}
+	if err := ps.checkpoint.load(); err != nil {
+		return err
+	}
+	pruneErr := ps.pruneCheckpoint(ctx)
+	allocs := ps.checkpoint.list()
+	restored := 0
+	for _, a := range allocs {
+		for _, d := range a.Devices {
+			if v := vnpuOf(a, d); v != nil {
+				ps.mgr.RestoreVNPU(v)
+				restored++
+			}
+		}
+	}
+	if pruneErr != nil {
+		return pruneErr
+	}
+	klog.Infof("checkpoint: %d allocation(s) of running pods, %d vNPU(s) restored", len(allocs), restored)
+	return nil
+}
+
+// pruneCheckpoint drops the allocations of pods that no longer run on the
+// node, removing their shmem dirs. Their vNPUs are forgotten, not destroyed:
+// the idle vNPU cleanup destroys them once no container uses them.
+func (ps *PluginServer) pruneCheckpoint(ctx context.Context) error {
+	if ps.checkpoint == nil {
+		return nil
+	}
+	live, err := ps.livePodUIDs(ctx)
+	if err != nil {
+		return err
+	}
+	dropped, err := ps.checkpoint.retain(func(a ContainerAllocation) bool {
+		return live[a.PodUID]
+	})
+	for _, a := range dropped {
+		klog.Infof("checkpoint: dropping allocation of terminated pod %s/%s container %s", a.Namespace, a.Pod, a.Container)
+		if a.ShmemDir != "" {
+			if rmErr := os.RemoveAll(a.ShmemDir); rmErr != nil {
+				klog.Warningf("remove shmem dir %s: %v", a.ShmemDir, rmErr)
+			}
+		}
+		for _, d := range a.Devices {
+			if v := vnpuOf(a, d); v != nil {
+				ps.mgr.ForgetVNPU(v)
+			}
+		}
+	}
+	return err
+}
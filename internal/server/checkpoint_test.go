@@ -0,0 +1,208 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+func TestAllocationCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "hami-ascend-Ascend910B3.checkpoint")
+	cp := newAllocationCheckpoint(path, "huawei.com/Ascend910B3")
+	if err := cp.load(); err != nil {
+		t.Fatalf("load() of missing file error: %v", err)
+	}
+	if got := cp.list(); len(got) != 0 {
+		t.Fatalf("list() of missing file = %v, want empty", got)
+	}
+
+	a := ContainerAllocation{PodUID: "uid-a", Namespace: "default", Pod: "a", Container: "c",
+		Devices: []AllocatedDevice{{UUID: "npu0", PhyID: 0, Memory: 1024, Core: 10}}}
+	b := ContainerAllocation{PodUID: "uid-b", Namespace: "default", Pod: "b", Container: "c",
+		Devices: []AllocatedDevice{{UUID: "npu1", PhyID: 1, VNPU: &CheckpointVNPU{LogicID: 1, VDevID: 100}}}}
+	if err := cp.add(a, b); err != nil {
+		t.Fatalf("add() error: %v", err)
+	}
+	// Re-allocating the same container replaces its record.
+	a.Devices[0].Memory = 2048
+	if err := cp.add(a); err != nil {
+		t.Fatalf("add() error: %v", err)
+	}
+
+	reloaded := newAllocationCheckpoint(path, "huawei.com/Ascend910B3")
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("load() error: %v", err)
+	}
+	if got, want := reloaded.list(), []ContainerAllocation{a, b}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("reloaded list() = %+v, want %+v", got, want)
+	}
+
+	dropped, err := reloaded.retain(func(c ContainerAllocation) bool { return c.PodUID == "uid-b" })
+	if err != nil {
+		t.Fatalf("retain() error: %v", err)
+	}
+	if len(dropped) != 1 || dropped[0].PodUID != "uid-a" {
+		t.Fatalf("retain() dropped %+v, want uid-a", dropped)
+	}
+	if err := cp.load(); err != nil {
+		t.Fatalf("load() error: %v", err)
+	}
+	if got := cp.list(); len(got) != 1 || got[0].PodUID != "uid-b" {
+		t.Fatalf("list() after retain = %+v, want only uid-b", got)
+	}
+}
+
+func TestBuildContainerAllocationRecord(t *testing.T) {
+	t.Parallel()
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "default", UID: "uid-p"}}
+	ps := &PluginServer{mgr: &FakeManager{
+		GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+			return &manager.Device{UUID: uuid, PhyID: 2}
+		},
+		CreateVNPUEnabledFunc: func() bool { return true },
+		CreateVNPUFunc: func(uuid, template, owner string) (*manager.VNPU, error) {
+			return &manager.VNPU{UUID: uuid, LogicID: 5, VDevID: 103, Template: template, Owner: owner}, nil
+		},
+	}}
+	containerDevs := device.ContainerDevices{cd("npu2", "Ascend310P", 3072, 25)}
+	rtInfo := map[string]RuntimeInfo{"npu2": {UUID: "npu2", Temp: "vir02"}}
+
+	_, alloc, err := ps.buildContainerAllocation(pod, "ctr", containerDevs, rtInfo)
+	if err != nil {
+		t.Fatalf("buildContainerAllocation() error: %v", err)
+	}
+	want := &ContainerAllocation{
+		PodUID: "uid-p", Namespace: "default", Pod: "p", Container: "ctr",
+		Devices: []AllocatedDevice{{
+			UUID: "npu2", PhyID: 2, Memory: 3072, Core: 25, Template: "vir02",
+			VNPU: &CheckpointVNPU{LogicID: 5, VDevID: 103},
+		}},
+		Allocated: alloc.Allocated,
+	}
+	if !reflect.DeepEqual(alloc, want) {
+		t.Fatalf("buildContainerAllocation() record = %+v, want %+v", alloc, want)
+	}
+}
+
+func TestReconcileCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	staleShmem := filepath.Join(dir, "uid-gone_c")
+	liveShmem := filepath.Join(dir, "uid-live_c")
+	for _, d := range []string{staleShmem, liveShmem} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, "hami-ascend-Ascend910B3.checkpoint")
+	seed := newAllocationCheckpoint(path, "huawei.com/Ascend910B3")
+	if err := seed.add(
+		ContainerAllocation{PodUID: "uid-live", Namespace: "default", Pod: "live", Container: "c", ShmemDir: liveShmem,
+			Devices: []AllocatedDevice{{UUID: "npu0", Template: "vir05_1c_16g", VNPU: &CheckpointVNPU{LogicID: 0, VDevID: 100}}}},
+		ContainerAllocation{PodUID: "uid-done", Namespace: "default", Pod: "done", Container: "c",
+			Devices: []AllocatedDevice{{UUID: "npu1", Template: "vir05_1c_16g", VNPU: &CheckpointVNPU{LogicID: 1, VDevID: 101}}}},
+		ContainerAllocation{PodUID: "uid-gone", Namespace: "default", Pod: "gone", Container: "c", ShmemDir: staleShmem},
+	); err != nil {
+		t.Fatalf("seed checkpoint: %v", err)
+	}
+
+	pod := func(name string, uid types.UID, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: uid},
+			Spec:       v1.PodSpec{NodeName: "test-node"},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	t.Cleanup(setupFakeClient([]*v1.Pod{
+		pod("live", "uid-live", v1.PodRunning),
+		pod("done", "uid-done", v1.PodSucceeded),
+	}, nil))
+
+	vnpus := map[uint32]*manager.VNPU{}
+	mgr := &FakeManager{
+		RestoreVNPUFunc: func(v *manager.VNPU) { vnpus[v.VDevID] = v },
+		ForgetVNPUFunc:  func(v *manager.VNPU) { delete(vnpus, v.VDevID) },
+	}
+	ps := &PluginServer{mgr: mgr, nodeName: "test-node", checkpoint: newAllocationCheckpoint(path, "huawei.com/Ascend910B3")}
+	if err := ps.reconcileCheckpoint(context.Background()); err != nil {
+		t.Fatalf("reconcileCheckpoint() error: %v", err)
+	}
+	if v := vnpus[100]; len(vnpus) != 1 || v == nil || v.Owner != "default/live/c" || v.UUID != "npu0" || v.LogicID != 0 {
+		t.Fatalf("restored vNPUs = %+v, want only vNPU 100 of default/live/c", vnpus)
+	}
+
+	got := ps.checkpoint.list()
+	if len(got) != 1 || got[0].PodUID != "uid-live" {
+		t.Fatalf("checkpoint after reconcile = %+v, want only uid-live", got)
+	}
+	if _, err := os.Stat(staleShmem); !os.IsNotExist(err) {
+		t.Fatalf("shmem dir of terminated pod should be removed, stat err = %v", err)
+	}
+	if _, err := os.Stat(liveShmem); err != nil {
+		t.Fatalf("shmem dir of running pod should be kept: %v", err)
+	}
+
+	// The periodic prune forgets the vNPU once its pod is gone.
+	t.Cleanup(setupFakeClient(nil, nil))
+	if err := ps.pruneCheckpoint(context.Background()); err != nil {
+		t.Fatalf("pruneCheckpoint() error: %v", err)
+	}
+	if len(ps.checkpoint.list()) != 0 || len(vnpus) != 0 {
+		t.Fatalf("after prune checkpoint = %+v, vNPUs = %+v, want both empty", ps.checkpoint.list(), vnpus)
+	}
+}
+
+func TestAllocationViews(t *testing.T) {
+	t.Parallel()
+
+	cp := newAllocationCheckpoint(filepath.Join(t.TempDir(), "cp"), "huawei.com/Ascend310P")
+	running := ContainerAllocation{PodUID: "uid-1", Namespace: "default", Pod: "p1", Container: "c1"}
+	gone := ContainerAllocation{PodUID: "uid-2", Namespace: "default", Pod: "p2", Container: "c1"}
+	if err := cp.add(running, gone); err != nil {
+		t.Fatal(err)
+	}
+	ps := &PluginServer{
+		mgr:        &FakeManager{ResourceNameFunc: func() string { return "huawei.com/Ascend310P" }},
+		checkpoint: cp,
+	}
+	assigned := []manager.ContainerDevices{
+		{Namespace: "default", Pod: "p1", Container: "c1", ResourceName: "huawei.com/Ascend310P", DeviceIDs: []string{"npu0-0"}},
+		{Namespace: "default", Pod: "p3", Container: "c1", ResourceName: "nvidia.com/gpu", DeviceIDs: []string{"GPU-0"}},
+	}
+
+	want := []AllocationView{
+		{ResourceName: "huawei.com/Ascend310P", Namespace: "default", Pod: "p1", Container: "c1", DeviceIDs: []string{"npu0-0"}, Checkpoint: &running},
+		{ResourceName: "huawei.com/Ascend310P", Namespace: "default", Pod: "p2", Container: "c1", Checkpoint: &gone},
+	}
+	if got := allocationViews([]*PluginServer{ps}, assigned); !reflect.DeepEqual(got, want) {
+		t.Fatalf("allocationViews() = %+v, want %+v", got, want)
+	}
+}
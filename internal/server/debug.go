@@ -0,0 +1,413 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+// AllocationView is the debug view of one container's devices: what kubelet
+// assigned and what the plugin recorded in its checkpoint. A view with a
+// checkpoint record but no kubelet device IDs belongs to a container kubelet
+// no longer tracks.
+type AllocationView struct {
+	ResourceName string               `json:"resourceName"`
+	Namespace    string               `json:"namespace"`
+	Pod          string               `json:"pod"`
+	Container    string               `json:"container"`
+	DeviceIDs    []string             `json:"deviceIDs"`
+	Checkpoint   *ContainerAllocation `json:"checkpoint,omitempty"`
+}
+
+// allocationViews joins kubelet's assignments of the servers' resources with
+// their checkpoint records.
+func allocationViews(servers []*PluginServer, assigned []manager.ContainerDevices) []AllocationView {
+	views := []AllocationView{}
+	index := map[string]int{}
+	key := func(resourceName, namespace, pod, container string) string {
+		return resourceName + "|" + namespace + "/" + pod + "/" + container
+	}
+	resources := map[string]bool{}
+	for _, ps := range servers {
+		resources[ps.mgr.ResourceName()] = true
+	}
+	for _, c := range assigned {
+		if !resources[c.ResourceName] {
+			continue
+		}
+		index[key(c.ResourceName, c.Namespace, c.Pod, c.Container)] = len(views)
+		views = append(views, AllocationView{
+			ResourceName: c.ResourceName,
+			Namespace:    c.Namespace,
+			Pod:          c.Pod,
+			Container:    c.Container,
+			DeviceIDs:    c.DeviceIDs,
+		})
+	}
+	for _, ps := range servers {
+		if ps.checkpoint == nil {
+			continue
+		}
+		resourceName := ps.mgr.ResourceName()
+		for _, a := range ps.checkpoint.list() {
+			a := a
+			if i, ok := index[key(resourceName, a.Namespace, a.Pod, a.Container)]; ok {
+				views[i].Checkpoint = &a
+				continue
+			}
+			views = append(views, AllocationView{
+				ResourceName: resourceName,
+				Namespace:    a.Namespace,
+				Pod:          a.Pod,
+				Container:    a.Container,
+				Checkpoint:   &a,
+			})
+		}
+	}
+	return views
+}
+
+// writeJSON writes v as the JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.Errorf("write debug response: %v", err)
+	}
+}
+
+// AllocationsHandler serves the allocations of the given servers as JSON,
+// using kubelet's pod-resources API as the source of truth for which
+// container holds which device.
+func AllocationsHandler(servers []*PluginServer, lister manager.PodResourcesLister) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assigned, err := lister.ListContainerDevices(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, allocationViews(servers, assigned))
+	})
+}
+
+// allocateHistorySize is how many Allocate calls each server keeps for the
+// debug API.
+const allocateHistorySize = 32
+
+// AllocateRecord is one Allocate call as kubelet sent it and as it was
+// answered.
+type AllocateRecord struct {
+	Time      time.Time                 `json:"time"`
+	Duration  string                    `json:"duration"`
+	Namespace string                    `json:"namespace,omitempty"`
+	Pod       string                    `json:"pod,omitempty"`
+	Request   *v1beta1.AllocateRequest  `json:"request"`
+	Response  *v1beta1.AllocateResponse `json:"response,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+}
+
+// RegisterRecord is the last node annotation payload sent to HAMi.
+type RegisterRecord struct {
+	Time        time.Time         `json:"time"`
+	Annotations map[string]string `json:"annotations"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// debugState keeps what the debug API shows beyond the manager's state.
+type debugState struct {
+	mu           sync.Mutex
+	allocates    []AllocateRecord
+	lastRegister *RegisterRecord
+}
+
+func (d *debugState) recordAllocate(start time.Time, pod *v1.Pod, req *v1beta1.AllocateRequest, resp *v1beta1.AllocateResponse, err error) {
+	rec := AllocateRecord{
+		Time:     start,
+		Duration: time.Since(start).String(),
+		Request:  req,
+		Response: resp,
+	}
+	if pod != nil {
+		rec.Namespace, rec.Pod = pod.Namespace, pod.Name
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.allocates) == allocateHistorySize {
+		d.allocates = append(d.allocates[:0], d.allocates[1:]...)
+	}
+	d.allocates = append(d.allocates, rec)
+}
+
+// allocateHistory returns the recorded Allocate calls, oldest first.
+func (d *debugState) allocateHistory() []AllocateRecord {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]AllocateRecord{}, d.allocates...)
+}
+
+func (d *debugState) recordRegister(annos map[string]string, err error) {
+	rec := &RegisterRecord{Time: time.Now(), Annotations: annos}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastRegister = rec
+}
+
+func (d *debugState) register() *RegisterRecord {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastRegister
+}
+
+// DeviceView is the debug view of one manager.Device.
+type DeviceView struct {
+	UUID          string   `json:"uuid"`
+	LogicID       int32    `json:"logicID"`
+	PhyID         int32    `json:"phyID"`
+	CardID        int32    `json:"cardID"`
+	DeviceID      int32    `json:"deviceID"`
+	Memory        int64    `json:"memory"`
+	AICore        int32    `json:"aiCore"`
+	Health        bool     `json:"health"`
+	FaultCodes    []string `json:"faultCodes,omitempty"`
+	FaultSeverity string   `json:"faultSeverity,omitempty"`
+	NUMANode      int      `json:"numaNode"`
+}
+
+// DevicesView is the debug view of one server's devices: what the manager
+// found and the device IDs advertised to kubelet for them.
+type DevicesView struct {
+	ResourceName string            `json:"resourceName"`
+	Devices      []DeviceView      `json:"devices"`
+	Advertised   []*v1beta1.Device `json:"advertised"`
+}
+
+func (ps *PluginServer) devicesView() DevicesView {
+	devs := ps.mgr.GetDevices()
+	view := DevicesView{
+		ResourceName: ps.mgr.ResourceName(),
+		Devices:      make([]DeviceView, 0, len(devs)),
+		Advertised:   ps.apiDevices(),
+	}
+	for _, dev := range devs {
+		view.Devices = append(view.Devices, DeviceView{
+			UUID:          dev.UUID,
+			LogicID:       dev.LogicID,
+			PhyID:         dev.PhyID,
+			CardID:        dev.CardID,
+			DeviceID:      dev.DeviceID,
+			Memory:        dev.Memory,
+			AICore:        dev.AICore,
+			Health:        dev.Health,
+			FaultCodes:    dev.FaultCodeStrings(),
+			FaultSeverity: string(dev.FaultSeverity),
+			NUMANode:      dev.NUMANode,
+		})
+	}
+	return view
+}
+
+// DevicesHandler serves the devices of the given servers as JSON.
+func DevicesHandler(servers []*PluginServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		views := make([]DevicesView, 0, len(servers))
+		for _, ps := range servers {
+			views = append(views, ps.devicesView())
+		}
+		writeJSON(w, views)
+	})
+}
+
+// DeviceFaults is the debug view of one device's fault state.
+type DeviceFaults struct {
+	ResourceName  string                `json:"resourceName"`
+	UUID          string                `json:"uuid"`
+	LogicID       int32                 `json:"logicID"`
+	PhyID         int32                 `json:"phyID"`
+	Health        bool                  `json:"health"`
+	FaultSeverity manager.FaultSeverity `json:"faultSeverity"`
+	FaultCodes    []string              `json:"faultCodes"`
+}
+
+// FaultsHandler serves the fault state of every device of the given servers
+// as JSON.
+func FaultsHandler(servers []*PluginServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		faults := []DeviceFaults{}
+		for _, ps := range servers {
+			for _, dev := range ps.mgr.GetDevices() {
+				faults = append(faults, DeviceFaults{
+					ResourceName:  ps.mgr.ResourceName(),
+					UUID:          dev.UUID,
+					LogicID:       dev.LogicID,
+					PhyID:         dev.PhyID,
+					Health:        dev.Health,
+					FaultSeverity: dev.FaultSeverity,
+					FaultCodes:    dev.FaultCodeStrings(),
+				})
+			}
+		}
+		writeJSON(w, faults)
+	})
+}
+
+// ConfigView is the debug view of one server's effective config.
+type ConfigView struct {
+	ResourceName string                  `json:"resourceName"`
+	Config       manager.EffectiveConfig `json:"config"`
+}
+
+// ConfigHandler serves the effective config of the given servers as JSON.
+func ConfigHandler(servers []*PluginServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		views := make([]ConfigView, 0, len(servers))
+		for _, ps := range servers {
+			views = append(views, ConfigView{ResourceName: ps.mgr.ResourceName(), Config: ps.mgr.EffectiveConfig()})
+		}
+		writeJSON(w, views)
+	})
+}
+
+// AllocateHistoryView is the debug view of one server's last Allocate calls.
+type AllocateHistoryView struct {
+	ResourceName string           `json:"resourceName"`
+	Allocates    []AllocateRecord `json:"allocates"`
+}
+
+// AllocateHistoryHandler serves the last Allocate calls of the given servers
+// as JSON, oldest first.
+func AllocateHistoryHandler(servers []*PluginServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		views := make([]AllocateHistoryView, 0, len(servers))
+		for _, ps := range servers {
+			views = append(views, AllocateHistoryView{ResourceName: ps.mgr.ResourceName(), Allocates: ps.debug.allocateHistory()})
+		}
+		writeJSON(w, views)
+	})
+}
+
+// RegisterView is the debug view of one server's last HAMi registration.
+type RegisterView struct {
+	ResourceName string          `json:"resourceName"`
+	Last         *RegisterRecord `json:"last"`
+}
+
+// RegisterHandler serves the last HAMi register payload of the given servers
+// as JSON.
+func RegisterHandler(servers []*PluginServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		views := make([]RegisterView, 0, len(servers))
+		for _, ps := range servers {
+			views = append(views, RegisterView{ResourceName: ps.mgr.ResourceName(), Last: ps.debug.register()})
+		}
+		writeJSON(w, views)
+	})
+}
+
+const (
+	debugReadHeaderTimeout = 10 * time.Second
+	debugWriteTimeout      = 30 * time.Second
+	debugIdleTimeout       = 2 * time.Minute
+)
+
+// readOnlyServer is a plain HTTP server of GET-only handlers, run on its own
+// address next to the metrics server.
+type readOnlyServer struct {
+	name string
+	addr string
+	mux  *http.ServeMux
+	srv  *http.Server
+}
+
+func newReadOnlyServer(name, addr string) *readOnlyServer {
+	mux := http.NewServeMux()
+	return &readOnlyServer{
+		name: name,
+		addr: addr,
+		mux:  mux,
+		srv: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: debugReadHeaderTimeout,
+			WriteTimeout:      debugWriteTimeout,
+			IdleTimeout:       debugIdleTimeout,
+		},
+	}
+}
+
+// Handle registers a read-only handler; other methods than GET and HEAD are
+// rejected.
+func (s *readOnlyServer) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+// Start binds the listen address and serves in the background. Bind errors
+// are returned; later serve errors are logged.
+func (s *readOnlyServer) Start() error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.addr, err)
+	}
+	klog.Infof("%s server starting on %s", s.name, s.addr)
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			klog.Errorf("%s server error: %v", s.name, err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests
+// until ctx is done.
+func (s *readOnlyServer) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// DebugServer serves the debug API on its own, usually loopback, address so
+// that config and allocation details are not exposed with the metrics.
+type DebugServer struct {
+	*readOnlyServer
+}
+
+// NewDebugServer builds a debug server listening on addr.
+func NewDebugServer(addr string) *DebugServer {
+	return &DebugServer{readOnlyServer: newReadOnlyServer("debug", addr)}
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+// ============================================================================
+// Allocate history
+// ============================================================================
+
+func TestAllocateHistory(t *testing.T) {
+	t.Run("FailedAllocateIsRecorded", func(t *testing.T) {
+		cleanup := setupFakeClient(nil, nil)
+		defer cleanup()
+		ps := &PluginServer{
+			commonWord: testCommonWord,
+			nodeName:   "missing-node",
+			mgr: &FakeManager{
+				ResourceNameFunc: func() string { return "test.io/debug-allocate" },
+			},
+		}
+		req := &v1beta1.AllocateRequest{
+			ContainerRequests: []*v1beta1.ContainerAllocateRequest{
+				{DevicesIds: []string{"uuid1-0"}},
+			},
+		}
+		_, err := ps.Allocate(context.Background(), req)
+		if err == nil {
+			t.Fatalf("Allocate() error = nil, want error")
+		}
+		history := ps.debug.allocateHistory()
+		if len(history) != 1 {
+			t.Fatalf("history has %d records, want 1", len(history))
+		}
+		rec := history[0]
+		if rec.Request != req || rec.Response != nil || rec.Error != err.Error() {
+			t.Errorf("record = %+v, want the request, no response and error %q", rec, err)
+		}
+	})
+
+	t.Run("OldestRecordsDropped", func(t *testing.T) {
+		var d debugState
+		for i := 0; i < allocateHistorySize+3; i++ {
+			d.recordAllocate(time.Now(), nil, &v1beta1.AllocateRequest{}, nil, fmt.Errorf("call %d", i))
+		}
+		history := d.allocateHistory()
+		if len(history) != allocateHistorySize {
+			t.Fatalf("history has %d records, want %d", len(history), allocateHistorySize)
+		}
+		if got, want := history[0].Error, "call 3"; got != want {
+			t.Errorf("oldest record = %q, want %q", got, want)
+		}
+		if got, want := history[len(history)-1].Error, fmt.Sprintf("call %d", allocateHistorySize+2); got != want {
+			t.Errorf("newest record = %q, want %q", got, want)
+		}
+	})
+}
+
+// ============================================================================
+// Debug handlers
+// ============================================================================
+
+func TestDebugHandlers(t *testing.T) {
+	ps := &PluginServer{mgr: &FakeManager{
+		ResourceNameFunc: func() string { return "huawei.com/Ascend910B4" },
+		VDeviceCountFunc: func() int { return 2 },
+		GetDevicesFunc: func() []*manager.Device {
+			return []*manager.Device{
+				{UUID: "uuid1", LogicID: 0, Health: true},
+				{UUID: "uuid2", LogicID: 1, Health: false, FaultCodes: []int64{0x80e01801}, FaultSeverity: manager.FaultSeverityCritical},
+			}
+		},
+		EffectiveConfigFunc: func() manager.EffectiveConfig {
+			return manager.EffectiveConfig{
+				ChipName:     "910B4",
+				VNPU:         internal.VNPUConfig{CommonWord: "Ascend910B4"},
+				Node:         &internal.NodeConfig{Name: "node-001", VDeviceCount: 2},
+				VDeviceCount: 2,
+			}
+		},
+	}}
+	ps.debug.recordRegister(map[string]string{"hami.io/node-register-Ascend910B4": "[]"}, errors.New("patch failed"))
+	servers := []*PluginServer{ps}
+
+	s := NewDebugServer("127.0.0.1:0")
+	s.Handle("/debug/devices", DevicesHandler(servers))
+	s.Handle("/debug/config", ConfigHandler(servers))
+	s.Handle("/debug/register", RegisterHandler(servers))
+	s.Handle("/debug/faults", FaultsHandler(servers))
+
+	get := func(t *testing.T, path string, v any) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d, want %d", path, rec.Code, http.StatusOK)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+
+	t.Run("Devices", func(t *testing.T) {
+		var views []DevicesView
+		get(t, "/debug/devices", &views)
+		if len(views) != 1 || len(views[0].Devices) != 2 {
+			t.Fatalf("views = %+v, want one resource with 2 devices", views)
+		}
+		if dev := views[0].Devices[1]; dev.Health || dev.FaultSeverity != string(manager.FaultSeverityCritical) || len(dev.FaultCodes) != 1 {
+			t.Errorf("device = %+v, want unhealthy with one fault code", dev)
+		}
+		var ids []string
+		for _, d := range views[0].Advertised {
+			ids = append(ids, d.ID+"="+d.Health)
+		}
+		want := fmt.Sprint([]string{"uuid1-0=Healthy", "uuid1-1=Healthy", "uuid2-0=Unhealthy", "uuid2-1=Unhealthy"})
+		if got := fmt.Sprint(ids); got != want {
+			t.Errorf("advertised = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Faults", func(t *testing.T) {
+		var faults []DeviceFaults
+		get(t, "/debug/faults", &faults)
+		want := []DeviceFaults{
+			{ResourceName: "huawei.com/Ascend910B4", UUID: "uuid1", Health: true, FaultCodes: []string{}},
+			{ResourceName: "huawei.com/Ascend910B4", UUID: "uuid2", LogicID: 1, FaultSeverity: manager.FaultSeverityCritical, FaultCodes: []string{"0x80E01801"}},
+		}
+		if !reflect.DeepEqual(faults, want) {
+			t.Errorf("faults = %+v, want %+v", faults, want)
+		}
+	})
+
+	t.Run("Config", func(t *testing.T) {
+		var views []ConfigView
+		get(t, "/debug/config", &views)
+		if len(views) != 1 || views[0].Config.VDeviceCount != 2 || views[0].Config.Node == nil || views[0].Config.Node.Name != "node-001" {
+			t.Errorf("views = %+v, want the effective config with the node entry", views)
+		}
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		var views []RegisterView
+		get(t, "/debug/register", &views)
+		if len(views) != 1 || views[0].Last == nil || views[0].Last.Error != "patch failed" ||
+			views[0].Last.Annotations["hami.io/node-register-Ascend910B4"] != "[]" {
+			t.Errorf("views = %+v, want the last payload with its error", views)
+		}
+	})
+
+	t.Run("WritesRejected", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/config", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("POST /debug/config = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+		}
+	})
+}
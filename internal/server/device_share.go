@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 )
 
@@ -117,8 +118,10 @@ func (ps *PluginServer) enableNodeDeviceShare() error {
 		chips = append(chips, c)
 	}
 	if err := applyDeviceShare(chips, true); err != nil {
+		ps.nodeEventf(v1.EventTypeWarning, EventReasonDeviceShareFailed, "enable device-share on %d %s chip(s) failed: %v", len(chips), ps.mgr.ResourceName(), err)
 		return fmt.Errorf("enable node device-share: %w", err)
 	}
 	klog.Infof("device-share enabled on %d chip(s) of node %s", len(chips), ps.nodeName)
+	ps.nodeEventf(v1.EventTypeNormal, EventReasonDeviceShareEnabled, "device-share enabled on %d %s chip(s)", len(chips), ps.mgr.ResourceName())
 	return nil
 }
@@ -24,6 +24,8 @@ import (
 	"strings"
 	"testing"
 
+	"k8s.io/client-go/tools/record"
+
 	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
 )
 
@@ -188,8 +190,10 @@ func TestEnableNodeDeviceShare_FlipsAllChipsDeduped(t *testing.T) {
 		seen[ic{args[4], args[6]}]++
 		return nil, nil
 	})
+	recorder := record.NewFakeRecorder(1)
 	ps := &PluginServer{
 		nodeName: "node-1",
+		recorder: recorder,
 		mgr: &FakeManager{
 			IsHamiVnpuCoreFunc: func() bool { return true },
 			GetDevicesFunc: func() []*manager.Device {
@@ -213,14 +217,19 @@ func TestEnableNodeDeviceShare_FlipsAllChipsDeduped(t *testing.T) {
 	if !reflect.DeepEqual(seen, want) {
 		t.Fatalf("device-share calls mismatch:\ngot  %v\nwant %v", seen, want)
 	}
+	if got := <-recorder.Events; !strings.HasPrefix(got, "Normal DeviceShareEnabled device-share enabled on 3 ") {
+		t.Fatalf("event = %q, want Normal DeviceShareEnabled for 3 chips", got)
+	}
 }
 
 func TestEnableNodeDeviceShare_FlipFailureFailsFast(t *testing.T) {
 	withFakeNpuSmi(t, func(args ...string) ([]byte, error) {
 		return []byte("E80001 not allowed"), fmt.Errorf("exit status 1")
 	})
+	recorder := record.NewFakeRecorder(1)
 	ps := &PluginServer{
 		nodeName: "node-1",
+		recorder: recorder,
 		mgr: &FakeManager{
 			IsHamiVnpuCoreFunc: func() bool { return true },
 			GetDevicesFunc: func() []*manager.Device {
@@ -231,4 +240,7 @@ func TestEnableNodeDeviceShare_FlipFailureFailsFast(t *testing.T) {
 	if err := ps.enableNodeDeviceShare(); err == nil {
 		t.Fatal("expected error when npu-smi flip fails, got nil")
 	}
+	if got := <-recorder.Events; !strings.HasPrefix(got, "Warning DeviceShareFailed ") {
+		t.Fatalf("event = %q, want Warning DeviceShareFailed", got)
+	}
 }
@@ -0,0 +1,290 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/HAMi/pkg/device/ascend"
+	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/HAMi/pkg/util/nodelock"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/plugintest"
+)
+
+const (
+	e2eNodeName     = "npu-node"
+	e2eCommonWord   = "Ascend910B3"
+	e2eResourceName = "huawei.com/Ascend910B3"
+)
+
+// e2eEnv is a plugin server for two simulated 910B3 chips, wired to a fake
+// kubelet and a fake API server.
+type e2eEnv struct {
+	kubelet *plugintest.Kubelet
+	api     *plugintest.APIServer
+	ps      *PluginServer
+}
+
+func newE2EEnv(t *testing.T) *e2eEnv {
+	t.Helper()
+	t.Cleanup(setupInRequestDevices(e2eCommonWord))
+	env := &e2eEnv{
+		kubelet: plugintest.NewKubelet(t),
+		api:     plugintest.NewAPIServer(t, e2eNodeName),
+	}
+	backend := plugintest.NewBackend(t, plugintest.Chips(2), plugintest.DeviceConfig)
+	ps, err := NewPluginServer(backend.Managers[0], e2eNodeName, 3600)
+	if err != nil {
+		t.Fatalf("NewPluginServer() error = %v", err)
+	}
+	ps.setDevicePluginDir(env.kubelet.Dir)
+	// Host preparation writes under /usr/local, which tests must not touch.
+	ps.prepareHostResourcesFunc = func() error { return nil }
+	env.ps = ps
+	t.Cleanup(func() { _ = ps.Stop() })
+	return env
+}
+
+func (env *e2eEnv) start(t *testing.T) {
+	t.Helper()
+	if err := env.ps.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+}
+
+// ============================================================================
+// Registration tests
+// ============================================================================
+
+func TestE2ERegistration(t *testing.T) {
+	env := newE2EEnv(t)
+	env.start(t)
+
+	regs := env.kubelet.Registrations()
+	if len(regs) != 1 {
+		t.Fatalf("kubelet got %d registrations, want 1", len(regs))
+	}
+	if regs[0].ResourceName != e2eResourceName || regs[0].Endpoint != e2eCommonWord+".sock" || regs[0].Version != v1beta1.Version {
+		t.Errorf("registration = %+v, want %s at %s.sock", regs[0], e2eResourceName, e2eCommonWord)
+	}
+
+	devices := env.kubelet.WaitForDevices(t, e2eResourceName, 0)
+	var ids []string
+	for _, d := range devices {
+		ids = append(ids, d.ID)
+		if d.Health != v1beta1.Healthy {
+			t.Errorf("device %s is %s, want Healthy", d.ID, d.Health)
+		}
+	}
+	wantIDs := []string{"uuid-0-0", "uuid-0-1", "uuid-0-2", "uuid-0-3", "uuid-1-0", "uuid-1-1", "uuid-1-2", "uuid-1-3"}
+	if strings.Join(ids, ",") != strings.Join(wantIDs, ",") {
+		t.Errorf("ListAndWatch devices = %v, want %v", ids, wantIDs)
+	}
+
+	var hamiDevices []device.DeviceInfo
+	anno := env.api.WaitForNodeAnnotation(t, "hami.io/node-register-"+e2eCommonWord)
+	if err := json.Unmarshal([]byte(anno), &hamiDevices); err != nil {
+		t.Fatalf("decode register annotation %q: %v", anno, err)
+	}
+	if len(hamiDevices) != 2 {
+		t.Fatalf("register annotation has %d devices, want 2", len(hamiDevices))
+	}
+	for i, d := range hamiDevices {
+		if d.ID != []string{"uuid-0", "uuid-1"}[i] || d.Count != 4 || d.Devmem != 65536 || d.Type != e2eCommonWord || !d.Health {
+			t.Errorf("register annotation device %d = %+v", i, d)
+		}
+	}
+	node := env.api.Node(t)
+	if !strings.HasPrefix(node.Annotations["hami.io/node-handshake-"+e2eCommonWord], "Reported_") {
+		t.Errorf("handshake annotation = %q, want Reported_<time>", node.Annotations["hami.io/node-handshake-"+e2eCommonWord])
+	}
+	if node.Annotations[VNPUNodeSelectorAnnotation] != "false" {
+		t.Errorf("%s annotation = %q, want false", VNPUNodeSelectorAnnotation, node.Annotations[VNPUNodeSelectorAnnotation])
+	}
+
+	if err := env.ps.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, err := os.Stat(env.ps.socket); !os.IsNotExist(err) {
+		t.Errorf("plugin socket still exists after Stop(): %v", err)
+	}
+}
+
+// ============================================================================
+// Allocate tests
+// ============================================================================
+
+func TestE2EAllocate(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		deviceIDs []string
+		wantEnvs  map[string]string
+	}{
+		{
+			name:      "WholeCard",
+			deviceIDs: []string{"uuid-1-0"},
+			wantEnvs:  map[string]string{"ASCEND_VISIBLE_DEVICES": "1"},
+		},
+		{
+			name:      "TemplateVNPU",
+			template:  "vir05_1c_16g",
+			deviceIDs: []string{"uuid-1-2"},
+			wantEnvs:  map[string]string{"ASCEND_VISIBLE_DEVICES": "1", "ASCEND_VNPU_SPECS": "vir05_1c_16g"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env := newE2EEnv(t)
+			env.start(t)
+			env.kubelet.WaitForDevices(t, e2eResourceName, 0)
+
+			pod := env.api.BindPod(t, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "npu-pod", Namespace: "default", UID: "pod-uid"},
+				Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "main"}}},
+			}, plugintest.Assignment{
+				CommonWord: e2eCommonWord,
+				Containers: device.PodSingleDevice{{cd("uuid-1", e2eCommonWord, 16384, 5)}},
+				RuntimeInfo: []ascend.RuntimeInfo{
+					{UUID: "uuid-1", Temp: tc.template},
+				},
+			})
+
+			resp, err := env.kubelet.Client(t, e2eResourceName).Allocate(context.Background(), &v1beta1.AllocateRequest{
+				ContainerRequests: []*v1beta1.ContainerAllocateRequest{{DevicesIds: tc.deviceIDs}},
+			})
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			if len(resp.ContainerResponses) != 1 {
+				t.Fatalf("Allocate() returned %d container responses, want 1", len(resp.ContainerResponses))
+			}
+			for k, want := range tc.wantEnvs {
+				if got := resp.ContainerResponses[0].Envs[k]; got != want {
+					t.Errorf("env %s = %q, want %q", k, got, want)
+				}
+			}
+
+			pod = env.api.Pod(t, pod.Namespace, pod.Name)
+			if phase := pod.Annotations[util.DeviceBindPhase]; phase != util.DeviceBindSuccess {
+				t.Errorf("bind phase = %q, want %q", phase, util.DeviceBindSuccess)
+			}
+			if _, locked := env.api.Node(t).Annotations[nodelock.NodeLockKey]; locked {
+				t.Error("node lock was not released")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Restart tests
+// ============================================================================
+
+// TestE2ERun drives the daemon's restart loop: it must register again when
+// kubelet recreates its socket, on SIGHUP and when a config reload asks for
+// it, and stop the plugin on SIGTERM.
+func TestE2ERun(t *testing.T) {
+	env := newE2EEnv(t)
+	configDir := t.TempDir()
+	var reloads atomic.Int32
+	sigs := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- Run([]*PluginServer{env.ps}, RunOptions{
+			DevicePluginDir: env.kubelet.Dir,
+			ConfigDirs:      []string{configDir},
+			Reload: func() bool {
+				// The first reload needs a restart, the second does not.
+				return reloads.Add(1) == 1
+			},
+			Signals: sigs,
+		})
+	}()
+
+	// waitForRegistrations waits until kubelet has seen n registrations and
+	// the plugin serves its devices again.
+	waitForRegistrations := func(t *testing.T, n int) {
+		t.Helper()
+		err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, plugintest.Timeout, true,
+			func(context.Context) (bool, error) { return len(env.kubelet.Registrations()) >= n, nil })
+		if err != nil {
+			t.Fatalf("kubelet got %d registrations, want %d", len(env.kubelet.Registrations()), n)
+		}
+		if got := len(env.kubelet.Registrations()); got != n {
+			t.Fatalf("kubelet got %d registrations, want %d", got, n)
+		}
+		if devices := env.kubelet.WaitForDevices(t, e2eResourceName, 0); len(devices) != 8 {
+			t.Errorf("ListAndWatch after registration %d sent %d devices, want 8", n, len(devices))
+		}
+	}
+	writeConfig := func(t *testing.T) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(configDir, "device-config.yaml"), []byte(time.Now().String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitForRegistrations(t, 1)
+
+	env.kubelet.Restart(t)
+	waitForRegistrations(t, 2)
+
+	sigs <- syscall.SIGHUP
+	waitForRegistrations(t, 3)
+
+	writeConfig(t)
+	waitForRegistrations(t, 4)
+
+	// A reload that needs no restart leaves the registration alone.
+	writeConfig(t)
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, plugintest.Timeout, true,
+		func(context.Context) (bool, error) { return reloads.Load() == 2, nil })
+	if err != nil {
+		t.Fatalf("config reloaded %d times, want 2", reloads.Load())
+	}
+	if got := len(env.kubelet.Registrations()); got != 4 {
+		t.Errorf("kubelet got %d registrations after a reload without changes, want 4", got)
+	}
+
+	sigs <- syscall.SIGTERM
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	case <-time.After(plugintest.Timeout):
+		t.Fatal("Run() did not return after SIGTERM")
+	}
+	if _, err := os.Stat(env.ps.socket); !os.IsNotExist(err) {
+		t.Errorf("plugin socket still exists after Run() returned: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const eventComponent = "hami-ascend-device-plugin"
+
+// Event reasons posted by the plugin.
+const (
+	EventReasonAllocateFailed     = "AllocateFailed"
+	EventReasonNPUUnhealthy       = "NPUUnhealthy"
+	EventReasonNPUHealthy         = "NPUHealthy"
+	EventReasonNPUAdded           = "NPUAdded"
+	EventReasonNPURemoved         = "NPURemoved"
+	EventReasonDeviceShareEnabled = "DeviceShareEnabled"
+	EventReasonDeviceShareFailed  = "DeviceShareFailed"
+	EventReasonIdleVNPUsCleaned   = "IdleVNPUsCleaned"
+	EventReasonVNPUCleanupFailed  = "VNPUCleanupFailed"
+)
+
+// EventRecorder posts Kubernetes Events. record.EventRecorder satisfies it,
+// and tests use record.FakeRecorder.
+type EventRecorder interface {
+	Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{})
+}
+
+// NewEventRecorder returns a recorder that posts events through kubeClient on
+// behalf of the plugin running on nodeName.
+func NewEventRecorder(kubeClient kubernetes.Interface, nodeName string) EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: eventComponent, Host: nodeName})
+}
+
+// SetEventRecorder makes the server post events through recorder. Without a
+// recorder no events are posted.
+func (ps *PluginServer) SetEventRecorder(recorder EventRecorder) {
+	ps.recorder = recorder
+}
+
+// nodeRef references the node the same way kubelet does for its own events.
+func (ps *PluginServer) nodeRef() *v1.ObjectReference {
+	return &v1.ObjectReference{Kind: "Node", Name: ps.nodeName, UID: types.UID(ps.nodeName)}
+}
+
+func (ps *PluginServer) nodeEventf(eventtype, reason, messageFmt string, args ...interface{}) {
+	if ps.recorder == nil {
+		return
+	}
+	ps.recorder.Eventf(ps.nodeRef(), eventtype, reason, messageFmt, args...)
+}
+
+func (ps *PluginServer) podEventf(pod *v1.Pod, eventtype, reason, messageFmt string, args ...interface{}) {
+	if ps.recorder == nil || pod == nil {
+		return
+	}
+	ps.recorder.Eventf(pod, eventtype, reason, messageFmt, args...)
+}
+
+// recordDeviceEvents posts one node event per device transition.
+func (ps *PluginServer) recordDeviceEvents(events []DeviceEvent) {
+	for _, e := range events {
+		deviceHealthTransitions.WithLabelValues(ps.mgr.ResourceName(), string(e.Type)).Inc()
+		eventtype, reason := v1.EventTypeNormal, ""
+		switch e.Type {
+		case DeviceUnhealthy:
+			eventtype, reason = v1.EventTypeWarning, EventReasonNPUUnhealthy
+		case DeviceRemoved:
+			eventtype, reason = v1.EventTypeWarning, EventReasonNPURemoved
+		case DeviceHealthy:
+			reason = EventReasonNPUHealthy
+		case DeviceAdded:
+			reason = EventReasonNPUAdded
+		default:
+			continue
+		}
+		ps.nodeEventf(eventtype, reason, "%s device %s (logic ID %d) is %s",
+			ps.mgr.ResourceName(), e.UUID, e.LogicID, e.Type)
+	}
+}
@@ -17,6 +17,7 @@
 package server
 
 import (
+	"github.com/Project-HAMi/ascend-device-plugin/internal"
 	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
 )
 
@@ -31,8 +32,17 @@ type FakeManager struct {
 	GetDevicesFunc       func() []*manager.Device
 	GetDeviceByUUIDFunc  func(UUID string) *manager.Device
 	GetUnHealthIDsFunc   func() []int32
-	CleanupIdleVNPUsFunc func() error
+	CleanupIdleVNPUsFunc func() (int, error)
 	IsHamiVnpuCoreFunc   func() bool
+
+	PreferredAllocationEnabledFunc func() bool
+	CreateVNPUEnabledFunc          func() bool
+	CreateVNPUFunc                 func(UUID string, template string, owner string) (*manager.VNPU, error)
+	DestroyVNPUFunc                func(vnpu *manager.VNPU) error
+	RestoreVNPUFunc                func(vnpu *manager.VNPU)
+	ForgetVNPUFunc                 func(vnpu *manager.VNPU)
+	EffectiveConfigFunc            func() manager.EffectiveConfig
+	ContainerProfileFunc           func() internal.ContainerProfile
 }
 
 func (f *FakeManager) CommonWord() string {
@@ -84,11 +94,11 @@ func (f *FakeManager) GetUnHealthIDs() []int32 {
 	return nil
 }
 
-func (f *FakeManager) CleanupIdleVNPUs() error {
+func (f *FakeManager) CleanupIdleVNPUs() (int, error) {
 	if f.CleanupIdleVNPUsFunc != nil {
 		return f.CleanupIdleVNPUsFunc()
 	}
-	return nil
+	return 0, nil
 }
 
 func (f *FakeManager) IsHamiVnpuCore() bool {
@@ -97,3 +107,57 @@ func (f *FakeManager) IsHamiVnpuCore() bool {
 	}
 	return false
 }
+
+func (f *FakeManager) PreferredAllocationEnabled() bool {
+	if f.PreferredAllocationEnabledFunc != nil {
+		return f.PreferredAllocationEnabledFunc()
+	}
+	return false
+}
+
+func (f *FakeManager) CreateVNPUEnabled() bool {
+	if f.CreateVNPUEnabledFunc != nil {
+		return f.CreateVNPUEnabledFunc()
+	}
+	return false
+}
+
+func (f *FakeManager) CreateVNPU(UUID string, template string, owner string) (*manager.VNPU, error) {
+	if f.CreateVNPUFunc != nil {
+		return f.CreateVNPUFunc(UUID, template, owner)
+	}
+	return nil, nil
+}
+
+func (f *FakeManager) DestroyVNPU(vnpu *manager.VNPU) error {
+	if f.DestroyVNPUFunc != nil {
+		return f.DestroyVNPUFunc(vnpu)
+	}
+	return nil
+}
+
+func (f *FakeManager) RestoreVNPU(vnpu *manager.VNPU) {
+	if f.RestoreVNPUFunc != nil {
+		f.RestoreVNPUFunc(vnpu)
+	}
+}
+
+func (f *FakeManager) ForgetVNPU(vnpu *manager.VNPU) {
+	if f.ForgetVNPUFunc != nil {
+		f.ForgetVNPUFunc(vnpu)
+	}
+}
+
+func (f *FakeManager) EffectiveConfig() manager.EffectiveConfig {
+	if f.EffectiveConfigFunc != nil {
+		return f.EffectiveConfigFunc()
+	}
+	return manager.EffectiveConfig{}
+}
+
+func (f *FakeManager) ContainerProfile() internal.ContainerProfile {
+	if f.ContainerProfileFunc != nil {
+		return f.ContainerProfileFunc()
+	}
+	return internal.DefaultContainerProfile()
+}
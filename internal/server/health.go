@@ -0,0 +1,107 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+// DeviceEventType is the kind of transition a device went through between two
+// device snapshots.
+type DeviceEventType string
+
+const (
+	DeviceAdded     DeviceEventType = "Added"
+	DeviceRemoved   DeviceEventType = "Removed"
+	DeviceUnhealthy DeviceEventType = "Unhealthy"
+	DeviceHealthy   DeviceEventType = "Healthy"
+)
+
+// DeviceEvent describes one device transition.
+type DeviceEvent struct {
+	Type    DeviceEventType
+	UUID    string
+	LogicID int32
+}
+
+// snapshotDevices copies the devices so later updates cannot change the
+// snapshot under the diff.
+func snapshotDevices(devs []*manager.Device) map[string]manager.Device {
+	snap := make(map[string]manager.Device, len(devs))
+	for _, dev := range devs {
+		snap[dev.UUID] = *dev
+	}
+	return snap
+}
+
+// diffDevices returns every transition between prev and cur: devices that
+// appeared or disappeared, and devices whose health flipped. Events are
+// ordered as the devices appear in cur, followed by the removed devices in
+// the order of prevOrder.
+func diffDevices(prevOrder []*manager.Device, prev map[string]manager.Device, cur []*manager.Device) []DeviceEvent {
+	var events []DeviceEvent
+	seen := make(map[string]bool, len(cur))
+	for _, dev := range cur {
+		seen[dev.UUID] = true
+		old, ok := prev[dev.UUID]
+		switch {
+		case !ok:
+			events = append(events, DeviceEvent{Type: DeviceAdded, UUID: dev.UUID, LogicID: dev.LogicID})
+		case old.Health && !dev.Health:
+			events = append(events, DeviceEvent{Type: DeviceUnhealthy, UUID: dev.UUID, LogicID: dev.LogicID})
+		case !old.Health && dev.Health:
+			events = append(events, DeviceEvent{Type: DeviceHealthy, UUID: dev.UUID, LogicID: dev.LogicID})
+		}
+	}
+	for _, dev := range prevOrder {
+		if !seen[dev.UUID] {
+			events = append(events, DeviceEvent{Type: DeviceRemoved, UUID: dev.UUID, LogicID: dev.LogicID})
+		}
+	}
+	return events
+}
+
+// checkDevices refreshes the device list and returns the transitions since
+// the previous refresh. The returned events are also logged.
+func (ps *PluginServer) checkDevices() ([]DeviceEvent, error) {
+	prevOrder := ps.mgr.GetDevices()
+	prev := snapshotDevices(prevOrder)
+	// A failed update still leaves the last known devices, with the failed
+	// chips marked unhealthy, so the events are reported either way.
+	err := ps.mgr.UpdateDevice()
+	events := diffDevices(prevOrder, prev, ps.mgr.GetDevices())
+	for _, e := range events {
+		if e.Type == DeviceUnhealthy || e.Type == DeviceRemoved {
+			klog.Warningf("device %s (logic ID %d) of %s: %s", e.UUID, e.LogicID, ps.mgr.ResourceName(), e.Type)
+		} else {
+			klog.Infof("device %s (logic ID %d) of %s: %s", e.UUID, e.LogicID, ps.mgr.ResourceName(), e.Type)
+		}
+	}
+	return events, err
+}
+
+// notifyDevicesChanged wakes ListAndWatch without blocking. healthCh holds at
+// most one pending notification; ListAndWatch always sends the latest device
+// list, so coalescing notifications loses nothing.
+func (ps *PluginServer) notifyDevicesChanged() {
+	select {
+	case ps.healthCh <- struct{}{}:
+	default:
+	}
+}
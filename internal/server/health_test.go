@@ -0,0 +1,205 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+func TestDiffDevices(t *testing.T) {
+	t.Parallel()
+
+	dev := func(uuid string, logicID int32, health bool) *manager.Device {
+		return &manager.Device{UUID: uuid, LogicID: logicID, Health: health}
+	}
+
+	tests := []struct {
+		name string
+		prev []*manager.Device
+		cur  []*manager.Device
+		want []DeviceEvent
+	}{
+		{
+			name: "NoChange",
+			prev: []*manager.Device{dev("a", 0, true), dev("b", 1, false)},
+			cur:  []*manager.Device{dev("a", 0, true), dev("b", 1, false)},
+		},
+		{
+			name: "BecomesUnhealthy",
+			prev: []*manager.Device{dev("a", 0, true)},
+			cur:  []*manager.Device{dev("a", 0, false)},
+			want: []DeviceEvent{{Type: DeviceUnhealthy, UUID: "a", LogicID: 0}},
+		},
+		{
+			name: "Recovers",
+			prev: []*manager.Device{dev("a", 0, false)},
+			cur:  []*manager.Device{dev("a", 0, true)},
+			want: []DeviceEvent{{Type: DeviceHealthy, UUID: "a", LogicID: 0}},
+		},
+		{
+			name: "AddedAndRemoved",
+			prev: []*manager.Device{dev("a", 0, true), dev("b", 1, true)},
+			cur:  []*manager.Device{dev("a", 0, true), dev("c", 2, false)},
+			want: []DeviceEvent{
+				{Type: DeviceAdded, UUID: "c", LogicID: 2},
+				{Type: DeviceRemoved, UUID: "b", LogicID: 1},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := diffDevices(tc.prev, snapshotDevices(tc.prev), tc.cur)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("diffDevices() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// fakeListAndWatchServer records every response sent on the stream.
+type fakeListAndWatchServer struct {
+	grpc.ServerStream
+	ctx  context.Context
+	mu   sync.Mutex
+	sent []*v1beta1.ListAndWatchResponse
+}
+
+func (f *fakeListAndWatchServer) Send(resp *v1beta1.ListAndWatchResponse) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, resp)
+	return nil
+}
+
+func (f *fakeListAndWatchServer) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeListAndWatchServer) responses() []*v1beta1.ListAndWatchResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*v1beta1.ListAndWatchResponse(nil), f.sent...)
+}
+
+// TestListAndWatchResendsOnRecovery checks that a device recovering from an
+// unhealthy state is re-advertised to kubelet as healthy.
+func TestListAndWatchResendsOnRecovery(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	devs := []*manager.Device{{UUID: "npu0", Health: false}}
+	ps := &PluginServer{
+		stopCh:   make(chan interface{}),
+		healthCh: make(chan struct{}, 1),
+		mgr: &FakeManager{
+			VDeviceCountFunc: func() int { return 1 },
+			GetDevicesFunc: func() []*manager.Device {
+				mu.Lock()
+				defer mu.Unlock()
+				return devs
+			},
+			UpdateDeviceFunc: func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				devs = []*manager.Device{{UUID: "npu0", Health: true}}
+				return nil
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeListAndWatchServer{ctx: ctx}
+	done := make(chan error, 1)
+	go func() { done <- ps.ListAndWatch(&v1beta1.Empty{}, stream) }()
+
+	events, err := ps.checkDevices()
+	if err != nil {
+		t.Fatalf("checkDevices() error: %v", err)
+	}
+	want := []DeviceEvent{{Type: DeviceHealthy, UUID: "npu0"}}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("checkDevices() = %+v, want %+v", events, want)
+	}
+	// A second notification must not block while the first is pending.
+	ps.notifyDevicesChanged()
+	ps.notifyDevicesChanged()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resps := stream.responses()
+		if len(resps) >= 2 {
+			last := resps[len(resps)-1].Devices
+			if len(last) != 1 || last[0].Health != v1beta1.Healthy {
+				t.Fatalf("last ListAndWatch response = %v, want npu0-0 healthy", last)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ListAndWatch sent %d responses, want at least 2", len(resps))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("ListAndWatch() error: %v", err)
+	}
+}
+
+func TestRecordDeviceEvents(t *testing.T) {
+	t.Parallel()
+
+	recorder := record.NewFakeRecorder(10)
+	ps := &PluginServer{
+		nodeName: "node-1",
+		recorder: recorder,
+		mgr:      &FakeManager{ResourceNameFunc: func() string { return "huawei.com/Ascend910B3" }},
+	}
+	ps.recordDeviceEvents([]DeviceEvent{
+		{Type: DeviceUnhealthy, UUID: "a", LogicID: 0},
+		{Type: DeviceHealthy, UUID: "b", LogicID: 1},
+		{Type: DeviceAdded, UUID: "c", LogicID: 2},
+		{Type: DeviceRemoved, UUID: "d", LogicID: 3},
+	})
+	close(recorder.Events)
+
+	var got []string
+	for e := range recorder.Events {
+		got = append(got, e)
+	}
+	want := []string{
+		"Warning NPUUnhealthy huawei.com/Ascend910B3 device a (logic ID 0) is Unhealthy",
+		"Normal NPUHealthy huawei.com/Ascend910B3 device b (logic ID 1) is Healthy",
+		"Normal NPUAdded huawei.com/Ascend910B3 device c (logic ID 2) is Added",
+		"Warning NPURemoved huawei.com/Ascend910B3 device d (logic ID 3) is Removed",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
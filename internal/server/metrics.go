@@ -0,0 +1,128 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Allocate failure reasons used as the "reason" label.
+const (
+	allocateReasonPendingPod      = "pending_pod"
+	allocateReasonRuntimeInfo     = "runtime_info"
+	allocateReasonDecode          = "decode_annotation"
+	allocateReasonNoContainer     = "no_container_devices"
+	allocateReasonCountMismatch   = "device_count_mismatch"
+	allocateReasonBuildResponse   = "build_response"
+	allocateReasonPatchAnnotation = "patch_annotation"
+	allocateReasonBindPhase       = "bind_phase"
+)
+
+var (
+	allocateRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_ascend_allocate_requests_total",
+		Help: "Allocate calls received from kubelet",
+	}, []string{"resource_name"})
+
+	allocateFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_ascend_allocate_failures_total",
+		Help: "Allocate calls that failed, by reason",
+	}, []string{"resource_name", "reason"})
+
+	allocateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hami_ascend_allocate_duration_seconds",
+		Help:    "Allocate latency in seconds",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"resource_name"})
+
+	registerHAMiTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_ascend_register_hami_total",
+		Help: "Node device registrations with HAMi, by result",
+	}, []string{"resource_name", "result"})
+
+	kubeletRegistrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_ascend_kubelet_registrations_total",
+		Help: "Successful registrations of the device plugin with kubelet",
+	}, []string{"resource_name"})
+
+	grpcServerRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_ascend_grpc_server_restarts_total",
+		Help: "Restarts of the device plugin gRPC server after it crashed",
+	}, []string{"resource_name"})
+
+	deviceHealthTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_ascend_device_health_transitions_total",
+		Help: "Device transitions seen by the health monitor, by type",
+	}, []string{"resource_name", "type"})
+
+	vnpusDestroyed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hami_ascend_vnpus_destroyed_total",
+		Help: "Idle vNPUs destroyed by the cleanup",
+	}, []string{"resource_name"})
+
+	handshakeAgeDesc = prometheus.NewDesc(
+		"hami_ascend_register_hami_last_success_age_seconds",
+		"Seconds since the last successful registration with HAMi",
+		[]string{"resource_name"}, nil,
+	)
+
+	lastHandshakes = &handshakeCollector{last: map[string]time.Time{}}
+)
+
+// handshakeCollector reports the age of the last successful HAMi
+// registration per resource, computed at scrape time.
+type handshakeCollector struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func (c *handshakeCollector) observe(resourceName string, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last[resourceName] = t
+}
+
+func (c *handshakeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- handshakeAgeDesc
+}
+
+func (c *handshakeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for resourceName, t := range c.last {
+		ch <- prometheus.MustNewConstMetric(handshakeAgeDesc, prometheus.GaugeValue, time.Since(t).Seconds(), resourceName)
+	}
+}
+
+// MetricsCollectors returns the collectors of the plugin's own operation, to
+// be registered with the metrics server.
+func MetricsCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		allocateRequests,
+		allocateFailures,
+		allocateDuration,
+		registerHAMiTotal,
+		kubeletRegistrations,
+		grpcServerRestarts,
+		deviceHealthTransitions,
+		vnpusDestroyed,
+		lastHandshakes,
+	}
+}
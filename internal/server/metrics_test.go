@@ -0,0 +1,123 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+// ============================================================================
+// Allocate metrics
+// ============================================================================
+
+func TestAllocateMetrics(t *testing.T) {
+	// Each case uses its own resource name so that the package-level counters
+	// start from zero.
+	tests := []struct {
+		name         string
+		resourceName string
+		wantReason   string
+	}{
+		{
+			name:         "PendingPodFailureCountedByReason",
+			resourceName: "test.io/allocate-pending-pod",
+			wantReason:   allocateReasonPendingPod,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cleanup := setupFakeClient(nil, nil)
+			defer cleanup()
+			ps := &PluginServer{
+				commonWord: testCommonWord,
+				nodeName:   "missing-node",
+				mgr: &FakeManager{
+					ResourceNameFunc: func() string { return tc.resourceName },
+				},
+			}
+			_, err := ps.Allocate(context.Background(), &v1beta1.AllocateRequest{
+				ContainerRequests: []*v1beta1.ContainerAllocateRequest{
+					{DevicesIds: []string{"uuid1-0"}},
+				},
+			})
+			if err == nil {
+				t.Fatalf("Allocate() error = nil, want error")
+			}
+			if got := testutil.ToFloat64(allocateRequests.WithLabelValues(tc.resourceName)); got != 1 {
+				t.Errorf("allocate requests = %v, want 1", got)
+			}
+			if got := testutil.ToFloat64(allocateFailures.WithLabelValues(tc.resourceName, tc.wantReason)); got != 1 {
+				t.Errorf("allocate failures{reason=%q} = %v, want 1", tc.wantReason, got)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Other plugin metrics
+// ============================================================================
+
+func TestCleanupIdleVNPUsMetric(t *testing.T) {
+	const resourceName = "test.io/cleanup-metric"
+	ps := &PluginServer{
+		mgr: &FakeManager{
+			ResourceNameFunc:     func() string { return resourceName },
+			CleanupIdleVNPUsFunc: func() (int, error) { return 3, nil },
+		},
+	}
+	if err := ps.CleanupIdleVNPUs(); err != nil {
+		t.Fatalf("CleanupIdleVNPUs() error = %v", err)
+	}
+	if got := testutil.ToFloat64(vnpusDestroyed.WithLabelValues(resourceName)); got != 3 {
+		t.Errorf("vnpus destroyed = %v, want 3", got)
+	}
+}
+
+func TestDeviceHealthTransitionsMetric(t *testing.T) {
+	const resourceName = "test.io/health-metric"
+	ps := &PluginServer{
+		mgr: &FakeManager{ResourceNameFunc: func() string { return resourceName }},
+	}
+	ps.recordDeviceEvents([]DeviceEvent{
+		{Type: DeviceUnhealthy, UUID: "uuid1"},
+		{Type: DeviceUnhealthy, UUID: "uuid2"},
+		{Type: DeviceHealthy, UUID: "uuid1"},
+	})
+	if got := testutil.ToFloat64(deviceHealthTransitions.WithLabelValues(resourceName, string(DeviceUnhealthy))); got != 2 {
+		t.Errorf("unhealthy transitions = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(deviceHealthTransitions.WithLabelValues(resourceName, string(DeviceHealthy))); got != 1 {
+		t.Errorf("healthy transitions = %v, want 1", got)
+	}
+}
+
+func TestHandshakeCollector(t *testing.T) {
+	c := &handshakeCollector{last: map[string]time.Time{}}
+	if got := testutil.CollectAndCount(c); got != 0 {
+		t.Fatalf("metrics before any handshake = %d, want 0", got)
+	}
+	c.observe("test.io/a", time.Now().Add(-time.Minute))
+	if got := testutil.ToFloat64(c); got < 60 {
+		t.Errorf("handshake age = %v, want >= 60", got)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+// preferredCandidate is one physical NPU that still has free kubelet device
+// IDs (one ID per vDevice slot) in the current request.
+type preferredCandidate struct {
+	uuid      string
+	index     int
+	networkID int
+	// used is the number of slots of this NPU that kubelet has already handed
+	// out, i.e. VDeviceCount() minus the free slots in the request.
+	used int
+	free []string
+}
+
+func (ps *PluginServer) GetPreferredAllocation(_ context.Context, reqs *v1beta1.PreferredAllocationRequest) (*v1beta1.PreferredAllocationResponse, error) {
+	if !ps.mgr.PreferredAllocationEnabled() {
+		return nil, fmt.Errorf("not supported")
+	}
+	resp := &v1beta1.PreferredAllocationResponse{}
+	for _, req := range reqs.ContainerRequests {
+		ids, err := ps.preferredDeviceIDs(req.AvailableDeviceIDs, req.MustIncludeDeviceIDs, int(req.AllocationSize))
+		if err != nil {
+			return nil, err
+		}
+		klog.V(4).Infof("preferred allocation for size %d: %v", req.AllocationSize, ids)
+		resp.ContainerResponses = append(resp.ContainerResponses, &v1beta1.ContainerPreferredAllocationResponse{
+			DeviceIDs: ids,
+		})
+	}
+	return resp, nil
+}
+
+// preferredDeviceIDs picks size device IDs out of available. MustInclude IDs
+// always come first. The rest are taken from distinct NPUs in the HCCS group
+// (see getDeviceNetworkID) that already holds the must-include devices, or
+// else the smallest group that fits the whole request. Within a group, NPUs
+// that already have slots handed out are preferred so that soft slices pack
+// onto partially used cards and leave whole cards free.
+func (ps *PluginServer) preferredDeviceIDs(available, mustInclude []string, size int) ([]string, error) {
+	if size > len(available) {
+		return nil, fmt.Errorf("allocation size %d exceeds %d available devices", size, len(available))
+	}
+	if len(mustInclude) > size {
+		return nil, fmt.Errorf("%d must-include devices exceed allocation size %d", len(mustInclude), size)
+	}
+
+	devs := ps.mgr.GetDevices()
+	vCount := ps.mgr.VDeviceCount()
+	commonWord := ps.mgr.CommonWord()
+	candidates := make(map[string]*preferredCandidate, len(devs))
+	for i, dev := range devs {
+		networkID := 0
+		if strings.HasPrefix(commonWord, Ascend910Prefix) {
+			var err error
+			networkID, err = ps.getDeviceNetworkID(i, commonWord)
+			if err != nil {
+				return nil, fmt.Errorf("get networkID error: %w", err)
+			}
+		}
+		candidates[dev.UUID] = &preferredCandidate{uuid: dev.UUID, index: i, networkID: networkID, used: vCount}
+	}
+
+	selected := make([]string, 0, size)
+	picked := make(map[string]bool, size)
+	pickedNPU := make(map[string]bool, size)
+	for _, id := range mustInclude {
+		if picked[id] {
+			continue
+		}
+		selected = append(selected, id)
+		picked[id] = true
+		pickedNPU[manager.DeviceUUIDFromID(id)] = true
+	}
+	for _, id := range available {
+		c, ok := candidates[manager.DeviceUUIDFromID(id)]
+		if !ok {
+			continue
+		}
+		c.used--
+		if !picked[id] {
+			c.free = append(c.free, id)
+		}
+	}
+
+	// Group the NPUs that are not already picked by HCCS network.
+	groups := map[int][]*preferredCandidate{}
+	for _, c := range candidates {
+		if len(c.free) > 0 && !pickedNPU[c.uuid] {
+			groups[c.networkID] = append(groups[c.networkID], c)
+		}
+	}
+	for _, g := range groups {
+		sort.Slice(g, func(i, j int) bool {
+			if g[i].used != g[j].used {
+				return g[i].used > g[j].used
+			}
+			return g[i].index < g[j].index
+		})
+	}
+
+	need := size - len(selected)
+	order := ps.preferredGroupOrder(groups, candidates, mustInclude, need)
+
+	// First pass takes one slot per NPU, second pass fills up with any slot
+	// left when the request needs more IDs than there are free NPUs.
+	for pass := 0; pass < 2 && len(selected) < size; pass++ {
+		for _, networkID := range order {
+			for _, c := range groups[networkID] {
+				for _, id := range c.free {
+					if len(selected) == size {
+						break
+					}
+					if picked[id] || (pass == 0 && pickedNPU[c.uuid]) {
+						continue
+					}
+					selected = append(selected, id)
+					picked[id] = true
+					pickedNPU[c.uuid] = true
+				}
+			}
+		}
+	}
+
+	// IDs kubelet offered that do not map to a known NPU, e.g. a device that
+	// went away between UpdateDevice and this call.
+	for _, id := range available {
+		if len(selected) == size {
+			break
+		}
+		if !picked[id] {
+			selected = append(selected, id)
+			picked[id] = true
+		}
+	}
+	return selected, nil
+}
+
+// preferredGroupOrder returns the HCCS network IDs in the order they should
+// be drawn from: the group of the first must-include device, then the
+// smallest group able to hold need NPUs on its own, then the rest by size.
+func (ps *PluginServer) preferredGroupOrder(groups map[int][]*preferredCandidate, candidates map[string]*preferredCandidate, mustInclude []string, need int) []int {
+	order := make([]int, 0, len(groups))
+	for networkID := range groups {
+		order = append(order, networkID)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		gi, gj := len(groups[order[i]]), len(groups[order[j]])
+		fitI, fitJ := gi >= need, gj >= need
+		if fitI != fitJ {
+			return fitI
+		}
+		if fitI && gi != gj {
+			return gi < gj
+		}
+		if !fitI && gi != gj {
+			return gi > gj
+		}
+		return order[i] < order[j]
+	})
+
+	for _, id := range mustInclude {
+		c, ok := candidates[manager.DeviceUUIDFromID(id)]
+		if !ok {
+			continue
+		}
+		for i, networkID := range order {
+			if networkID == c.networkID {
+				order = append([]int{networkID}, append(order[:i:i], order[i+1:]...)...)
+				break
+			}
+		}
+		break
+	}
+	return order
+}
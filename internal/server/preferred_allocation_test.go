@@ -0,0 +1,264 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
+)
+
+// eightNPUs returns eight healthy 910B devices npu0..npu7; getDeviceNetworkID
+// puts npu0-3 in HCCS group 0 and npu4-7 in group 1.
+func eightNPUs() []*manager.Device {
+	devs := make([]*manager.Device, 0, 8)
+	for i := 0; i < 8; i++ {
+		devs = append(devs, &manager.Device{UUID: fmt.Sprintf("npu%d", i), PhyID: int32(i), Health: true})
+	}
+	return devs
+}
+
+// slotIDs returns the kubelet device IDs "<uuid>-<slot>" for the given slots.
+func slotIDs(uuid string, slots ...int) []string {
+	ids := make([]string, 0, len(slots))
+	for _, s := range slots {
+		ids = append(ids, fmt.Sprintf("%s-%d", uuid, s))
+	}
+	return ids
+}
+
+func concatIDs(parts ...[]string) []string {
+	var out []string
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func TestPreferredDeviceIDs(t *testing.T) {
+	t.Parallel()
+
+	type preferredArgs struct {
+		commonWord  string
+		vCount      int
+		available   []string
+		mustInclude []string
+		size        int
+	}
+
+	tests := []struct {
+		name    string
+		args    preferredArgs
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "WholeCardsStayInOneHCCSGroup",
+			args: preferredArgs{
+				commonWord: "Ascend910B3",
+				vCount:     1,
+				// npu0 and npu1 are taken, so group 0 has only two NPUs left
+				// and a 4-card request must land entirely in group 1.
+				available: concatIDs(slotIDs("npu2", 0), slotIDs("npu3", 0), slotIDs("npu4", 0),
+					slotIDs("npu5", 0), slotIDs("npu6", 0), slotIDs("npu7", 0)),
+				size: 4,
+			},
+			want: []string{"npu4-0", "npu5-0", "npu6-0", "npu7-0"},
+		},
+		{
+			name: "SmallestFittingGroupWins",
+			args: preferredArgs{
+				commonWord: "Ascend910B3",
+				vCount:     1,
+				available: concatIDs(slotIDs("npu1", 0), slotIDs("npu2", 0), slotIDs("npu3", 0),
+					slotIDs("npu6", 0), slotIDs("npu7", 0)),
+				size: 2,
+			},
+			want: []string{"npu6-0", "npu7-0"},
+		},
+		{
+			name: "MustIncludePullsItsGroup",
+			args: preferredArgs{
+				commonWord: "Ascend910B3",
+				vCount:     1,
+				available: concatIDs(slotIDs("npu1", 0), slotIDs("npu2", 0), slotIDs("npu3", 0),
+					slotIDs("npu5", 0), slotIDs("npu6", 0), slotIDs("npu7", 0)),
+				mustInclude: slotIDs("npu1", 0),
+				size:        3,
+			},
+			want: []string{"npu1-0", "npu2-0", "npu3-0"},
+		},
+		{
+			name: "SpansGroupsWhenNoneFits",
+			args: preferredArgs{
+				commonWord: "Ascend910B3",
+				vCount:     1,
+				available: concatIDs(slotIDs("npu0", 0), slotIDs("npu1", 0), slotIDs("npu2", 0),
+					slotIDs("npu3", 0), slotIDs("npu4", 0), slotIDs("npu5", 0)),
+				size: 6,
+			},
+			want: []string{"npu0-0", "npu1-0", "npu2-0", "npu3-0", "npu4-0", "npu5-0"},
+		},
+		{
+			name: "SoftSlicePrefersPartiallyUsedNPU",
+			args: preferredArgs{
+				commonWord: "Ascend910B3",
+				vCount:     4,
+				// npu2 already has two of its four slots handed out.
+				available: concatIDs(slotIDs("npu0", 0, 1, 2, 3), slotIDs("npu1", 0, 1, 2, 3),
+					slotIDs("npu2", 2, 3), slotIDs("npu3", 0, 1, 2, 3)),
+				size: 1,
+			},
+			want: []string{"npu2-2"},
+		},
+		{
+			name: "SoftSliceUsesDistinctNPUsFirst",
+			args: preferredArgs{
+				commonWord: "Ascend910B3",
+				vCount:     2,
+				available: concatIDs(slotIDs("npu0", 0, 1), slotIDs("npu1", 1),
+					slotIDs("npu2", 0, 1), slotIDs("npu3", 0, 1)),
+				size: 2,
+			},
+			want: []string{"npu1-1", "npu0-0"},
+		},
+		{
+			name: "FallsBackToSecondSlotOfSameNPU",
+			args: preferredArgs{
+				commonWord: "Ascend310P",
+				vCount:     4,
+				available:  slotIDs("npu0", 0, 1, 2),
+				size:       2,
+			},
+			want: []string{"npu0-0", "npu0-1"},
+		},
+		{
+			name: "UnknownIDsFillTheTail",
+			args: preferredArgs{
+				commonWord: "Ascend910B3",
+				vCount:     1,
+				available:  []string{"npu0-0", "gone-0"},
+				size:       2,
+			},
+			want: []string{"npu0-0", "gone-0"},
+		},
+		{
+			name: "SizeExceedsAvailable",
+			args: preferredArgs{
+				commonWord: "Ascend910B3",
+				vCount:     1,
+				available:  slotIDs("npu0", 0),
+				size:       2,
+			},
+			wantErr: "exceeds",
+		},
+		{
+			name: "MustIncludeExceedsSize",
+			args: preferredArgs{
+				commonWord:  "Ascend910B3",
+				vCount:      1,
+				available:   concatIDs(slotIDs("npu0", 0), slotIDs("npu1", 0)),
+				mustInclude: concatIDs(slotIDs("npu0", 0), slotIDs("npu1", 0)),
+				size:        1,
+			},
+			wantErr: "must-include",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ps := &PluginServer{mgr: &FakeManager{
+				CommonWordFunc:   func() string { return tc.args.commonWord },
+				VDeviceCountFunc: func() int { return tc.args.vCount },
+				GetDevicesFunc:   eightNPUs,
+			}}
+			got, err := ps.preferredDeviceIDs(tc.args.available, tc.args.mustInclude, tc.args.size)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("preferredDeviceIDs() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("preferredDeviceIDs() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("preferredDeviceIDs() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetPreferredAllocation(t *testing.T) {
+	t.Parallel()
+
+	req := &v1beta1.PreferredAllocationRequest{
+		ContainerRequests: []*v1beta1.ContainerPreferredAllocationRequest{
+			{AvailableDeviceIDs: concatIDs(slotIDs("npu0", 0), slotIDs("npu4", 0), slotIDs("npu5", 0)), AllocationSize: 2},
+		},
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		ps := &PluginServer{mgr: &FakeManager{}}
+		if _, err := ps.GetPreferredAllocation(context.Background(), req); err == nil {
+			t.Fatal("expected error when preferred allocation is disabled")
+		}
+		opts, err := ps.GetDevicePluginOptions(context.Background(), &v1beta1.Empty{})
+		if err != nil {
+			t.Fatalf("GetDevicePluginOptions() error: %v", err)
+		}
+		if opts.GetPreferredAllocationAvailable {
+			t.Fatal("GetPreferredAllocationAvailable = true, want false")
+		}
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		t.Parallel()
+		ps := &PluginServer{mgr: &FakeManager{
+			CommonWordFunc:                 func() string { return "Ascend910B3" },
+			VDeviceCountFunc:               func() int { return 1 },
+			GetDevicesFunc:                 eightNPUs,
+			PreferredAllocationEnabledFunc: func() bool { return true },
+		}}
+		resp, err := ps.GetPreferredAllocation(context.Background(), req)
+		if err != nil {
+			t.Fatalf("GetPreferredAllocation() error: %v", err)
+		}
+		if len(resp.ContainerResponses) != 1 {
+			t.Fatalf("expected 1 container response, got %d", len(resp.ContainerResponses))
+		}
+		want := []string{"npu4-0", "npu5-0"}
+		if got := resp.ContainerResponses[0].DeviceIDs; !reflect.DeepEqual(got, want) {
+			t.Fatalf("DeviceIDs = %v, want %v", got, want)
+		}
+		opts, err := ps.GetDevicePluginOptions(context.Background(), &v1beta1.Empty{})
+		if err != nil {
+			t.Fatalf("GetDevicePluginOptions() error: %v", err)
+		}
+		if !opts.GetPreferredAllocationAvailable {
+			t.Fatal("GetPreferredAllocationAvailable = false, want true")
+		}
+	})
+}
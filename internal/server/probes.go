@@ -0,0 +1,210 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// handshakeMaxAge is how old the last successful HAMi registration may be
+	// before the plugin is not ready. registerHAMi runs every 30s.
+	handshakeMaxAge = 2 * time.Minute
+	// watchLoopMaxAge is how long the watch loop may go without an iteration
+	// before the plugin is not live. The loop ticks at least every 30s.
+	watchLoopMaxAge  = 3 * time.Minute
+	probeDialTimeout = time.Second
+)
+
+// probeState records what the liveness and readiness checks look at.
+type probeState struct {
+	mu            sync.Mutex
+	registered    bool
+	started       time.Time
+	lastLoop      time.Time
+	lastHandshake time.Time
+	updateErr     error
+	updated       bool
+}
+
+func (p *probeState) setRegistered(registered bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.registered = registered
+	if registered {
+		p.started = time.Now()
+	}
+}
+
+func (p *probeState) loopTick() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastLoop = time.Now()
+}
+
+func (p *probeState) handshakeSucceeded() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastHandshake = time.Now()
+}
+
+func (p *probeState) deviceUpdated(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.updated = true
+	p.updateErr = err
+}
+
+// probeCheck is one named check; a nil error means it passed.
+type probeCheck struct {
+	name string
+	err  error
+}
+
+// livenessChecks reports whether the watch loop is still making progress.
+func (ps *PluginServer) livenessChecks() []probeCheck {
+	ps.probe.mu.Lock()
+	started, lastLoop := ps.probe.started, ps.probe.lastLoop
+	ps.probe.mu.Unlock()
+
+	name := ps.mgr.ResourceName() + "/watch-loop"
+	// A restart counts as progress until the new loop's first tick.
+	last := lastLoop
+	if started.After(last) {
+		last = started
+	}
+	var err error
+	if !last.IsZero() && time.Since(last) > watchLoopMaxAge {
+		err = fmt.Errorf("no iteration for %s", time.Since(last).Round(time.Second))
+	}
+	return []probeCheck{{name: name, err: err}}
+}
+
+// readinessChecks reports whether the plugin can serve allocations.
+func (ps *PluginServer) readinessChecks() []probeCheck {
+	ps.probe.mu.Lock()
+	registered, lastHandshake := ps.probe.registered, ps.probe.lastHandshake
+	updated, updateErr := ps.probe.updated, ps.probe.updateErr
+	ps.probe.mu.Unlock()
+
+	prefix := ps.mgr.ResourceName() + "/"
+	checks := make([]probeCheck, 0, 4)
+
+	var err error
+	if !registered {
+		err = fmt.Errorf("not registered with kubelet")
+	}
+	checks = append(checks, probeCheck{name: prefix + "kubelet-registration", err: err})
+
+	err = nil
+	if registered {
+		conn, dialErr := ps.dial(ps.socket, probeDialTimeout)
+		if dialErr != nil {
+			err = fmt.Errorf("dial %s: %w", ps.socket, dialErr)
+		} else {
+			_ = conn.Close()
+		}
+	} else {
+		err = fmt.Errorf("server not started")
+	}
+	checks = append(checks, probeCheck{name: prefix + "grpc-socket", err: err})
+
+	err = nil
+	switch {
+	case lastHandshake.IsZero():
+		err = fmt.Errorf("no successful registration with HAMi")
+	case time.Since(lastHandshake) > handshakeMaxAge:
+		err = fmt.Errorf("last successful registration with HAMi %s ago", time.Since(lastHandshake).Round(time.Second))
+	}
+	checks = append(checks, probeCheck{name: prefix + "hami-handshake", err: err})
+
+	err = nil
+	switch {
+	case !updated:
+		err = fmt.Errorf("devices not queried yet")
+	case updateErr != nil:
+		err = fmt.Errorf("update devices: %w", updateErr)
+	}
+	checks = append(checks, probeCheck{name: prefix + "dcmi", err: err})
+	return checks
+}
+
+// writeProbe writes the checks in the "[+]name ok" / "[-]name failed: reason"
+// form of the Kubernetes components and fails the request if any failed.
+func writeProbe(w http.ResponseWriter, checks []probeCheck) {
+	var b strings.Builder
+	failed := false
+	for _, c := range checks {
+		if c.err != nil {
+			failed = true
+			fmt.Fprintf(&b, "[-]%s failed: %v\n", c.name, c.err)
+		} else {
+			fmt.Fprintf(&b, "[+]%s ok\n", c.name)
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if failed {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		b.WriteString("check failed\n")
+	} else {
+		b.WriteString("ok\n")
+	}
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// HealthzHandler serves the liveness of the given servers.
+func HealthzHandler(servers []*PluginServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var checks []probeCheck
+		for _, ps := range servers {
+			checks = append(checks, ps.livenessChecks()...)
+		}
+		writeProbe(w, checks)
+	})
+}
+
+// ReadyzHandler serves the readiness of the given servers.
+func ReadyzHandler(servers []*PluginServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var checks []probeCheck
+		for _, ps := range servers {
+			checks = append(checks, ps.readinessChecks()...)
+		}
+		writeProbe(w, checks)
+	})
+}
+
+// ProbeServer serves /healthz and /readyz over plain HTTP on its own address,
+// so that kubelet's httpGet probes keep working when the metrics server
+// requires TLS or client certificates.
+type ProbeServer struct {
+	*readOnlyServer
+}
+
+// NewProbeServer builds a probe server for the given servers listening on
+// addr.
+func NewProbeServer(addr string, servers []*PluginServer) *ProbeServer {
+	s := &ProbeServer{readOnlyServer: newReadOnlyServer("probe", addr)}
+	s.Handle("/healthz", HealthzHandler(servers))
+	s.Handle("/readyz", ReadyzHandler(servers))
+	return s
+}
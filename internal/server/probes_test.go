@@ -0,0 +1,198 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ============================================================================
+// Readiness
+// ============================================================================
+
+func okDial(string, time.Duration) (*grpc.ClientConn, error) {
+	return grpc.NewClient("passthrough:///probe-test", grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+func TestReadyzHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(ps *PluginServer)
+		dial       func(string, time.Duration) (*grpc.ClientConn, error)
+		wantStatus int
+		wantLines  []string
+	}{
+		{
+			name: "AllChecksPass",
+			setup: func(ps *PluginServer) {
+				ps.probe.setRegistered(true)
+				ps.probe.handshakeSucceeded()
+				ps.probe.deviceUpdated(nil)
+			},
+			dial:       okDial,
+			wantStatus: http.StatusOK,
+			wantLines: []string{
+				"[+]huawei.com/Ascend910/kubelet-registration ok",
+				"[+]huawei.com/Ascend910/grpc-socket ok",
+				"[+]huawei.com/Ascend910/hami-handshake ok",
+				"[+]huawei.com/Ascend910/dcmi ok",
+				"ok",
+			},
+		},
+		{
+			name:       "NotStarted",
+			setup:      func(ps *PluginServer) {},
+			dial:       okDial,
+			wantStatus: http.StatusServiceUnavailable,
+			wantLines: []string{
+				"[-]huawei.com/Ascend910/kubelet-registration failed: not registered with kubelet",
+				"[-]huawei.com/Ascend910/grpc-socket failed: server not started",
+				"[-]huawei.com/Ascend910/hami-handshake failed: no successful registration with HAMi",
+				"[-]huawei.com/Ascend910/dcmi failed: devices not queried yet",
+				"check failed",
+			},
+		},
+		{
+			name: "SocketAndDCMIFailuresReportedByName",
+			setup: func(ps *PluginServer) {
+				ps.probe.setRegistered(true)
+				ps.probe.handshakeSucceeded()
+				ps.probe.deviceUpdated(fmt.Errorf("dcmi unavailable"))
+			},
+			dial: func(string, time.Duration) (*grpc.ClientConn, error) {
+				return nil, fmt.Errorf("connection refused")
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantLines: []string{
+				"[+]huawei.com/Ascend910/kubelet-registration ok",
+				"[-]huawei.com/Ascend910/grpc-socket failed: dial /tmp/probe.sock: connection refused",
+				"[+]huawei.com/Ascend910/hami-handshake ok",
+				"[-]huawei.com/Ascend910/dcmi failed: update devices: dcmi unavailable",
+				"check failed",
+			},
+		},
+		{
+			name: "StaleHandshake",
+			setup: func(ps *PluginServer) {
+				ps.probe.setRegistered(true)
+				ps.probe.deviceUpdated(nil)
+				ps.probe.lastHandshake = time.Now().Add(-handshakeMaxAge - time.Minute)
+			},
+			dial:       okDial,
+			wantStatus: http.StatusServiceUnavailable,
+			wantLines: []string{
+				"[+]huawei.com/Ascend910/kubelet-registration ok",
+				"[+]huawei.com/Ascend910/grpc-socket ok",
+				"[-]huawei.com/Ascend910/hami-handshake failed: last successful registration with HAMi 3m0s ago",
+				"[+]huawei.com/Ascend910/dcmi ok",
+				"check failed",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ps := &PluginServer{
+				socket:   "/tmp/probe.sock",
+				mgr:      &FakeManager{ResourceNameFunc: func() string { return "huawei.com/Ascend910" }},
+				dialFunc: tc.dial,
+			}
+			tc.setup(ps)
+
+			rec := httptest.NewRecorder()
+			ReadyzHandler([]*PluginServer{ps}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			got := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+			if strings.Join(got, "\n") != strings.Join(tc.wantLines, "\n") {
+				t.Errorf("body =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.wantLines, "\n"))
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Liveness
+// ============================================================================
+
+func TestHealthzHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		started    time.Time
+		lastLoop   time.Time
+		wantStatus int
+	}{
+		{name: "NeverStarted", wantStatus: http.StatusOK},
+		{name: "LoopTicking", started: time.Now().Add(-time.Hour), lastLoop: time.Now(), wantStatus: http.StatusOK},
+		{name: "LoopStuck", started: time.Now().Add(-time.Hour), lastLoop: time.Now().Add(-watchLoopMaxAge - time.Minute), wantStatus: http.StatusServiceUnavailable},
+		{name: "RestartedRecently", started: time.Now(), lastLoop: time.Now().Add(-time.Hour), wantStatus: http.StatusOK},
+		{name: "StuckBeforeFirstTick", started: time.Now().Add(-watchLoopMaxAge - time.Minute), wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ps := &PluginServer{mgr: &FakeManager{}}
+			ps.probe.started = tc.started
+			ps.probe.lastLoop = tc.lastLoop
+
+			rec := httptest.NewRecorder()
+			HealthzHandler([]*PluginServer{ps}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d, body:\n%s", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Probe server
+// ============================================================================
+
+func TestProbeServer(t *testing.T) {
+	ps := &PluginServer{mgr: &FakeManager{}}
+	s := NewProbeServer("127.0.0.1:0", []*PluginServer{ps})
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{method: http.MethodGet, path: "/healthz", wantStatus: http.StatusOK},
+		{method: http.MethodGet, path: "/readyz", wantStatus: http.StatusServiceUnavailable},
+		{method: http.MethodPost, path: "/healthz", wantStatus: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: "/metrics", wantStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/debug/faults", wantStatus: http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method+tc.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.mux.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("%s %s = %d, want %d", tc.method, tc.path, rec.Code, tc.wantStatus)
+			}
+		})
+	}
+}
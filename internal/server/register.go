@@ -33,6 +33,7 @@ import (
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/util"
+	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
 )
 
 // watchAndRegister must be launched with ps.wg.Add(1) already called by the
@@ -47,26 +48,47 @@ func (ps *PluginServer) watchAndRegister() {
 			return
 		case <-timer:
 		}
-		unhealthy := ps.mgr.GetUnHealthIDs()
-		if len(unhealthy) > 0 {
-			if err := ps.mgr.UpdateDevice(); err != nil {
-				klog.Errorf("update device error: %v", err)
-				timer = time.After(5 * time.Second)
-				continue
-			}
-			ps.healthCh <- unhealthy[0]
+		ps.probe.loopTick()
+		// A failed device query does not stop the handshake: the last known
+		// devices are advertised with the failed chips unhealthy, so HAMi
+		// stops scheduling to them instead of timing the node out.
+		events, updateErr := ps.checkDevices()
+		ps.probe.deviceUpdated(updateErr)
+		if updateErr != nil {
+			klog.Errorf("update device error: %v", updateErr)
+		}
+		if len(events) > 0 {
+			ps.recordDeviceEvents(events)
+			ps.notifyDevicesChanged()
 		}
 		err := ps.registerHAMi()
+		resourceName := ps.mgr.ResourceName()
 		if err != nil {
 			klog.Errorf("register HAMi error: %v", err)
-			timer = time.After(5 * time.Second)
+			registerHAMiTotal.WithLabelValues(resourceName, "failure").Inc()
 		} else {
 			klog.V(3).Infof("register HAMi success")
+			registerHAMiTotal.WithLabelValues(resourceName, "success").Inc()
+			lastHandshakes.observe(resourceName, time.Now())
+			ps.probe.handshakeSucceeded()
+		}
+		if err != nil || updateErr != nil {
+			timer = time.After(5 * time.Second)
+		} else {
 			timer = time.After(30 * time.Second)
 		}
 	}
 }
 
+// hamiNuma returns the NUMA node HAMi records for dev. HAMi has no value for
+// "no affinity", so such devices stay on node 0 as before.
+func hamiNuma(dev *manager.Device) int {
+	if dev.NUMANode == manager.NoNUMANode {
+		return 0
+	}
+	return dev.NUMANode
+}
+
 func (ps *PluginServer) registerHAMi() error {
 	devs := ps.mgr.GetDevices()
 	apiDevices := make([]*device.DeviceInfo, 0, len(devs))
@@ -83,7 +105,7 @@ func (ps *PluginServer) registerHAMi() error {
 			Devmem:  int32(dev.Memory),
 			Devcore: devcore,
 			Type:    ps.mgr.CommonWord(),
-			Numa:    0,
+			Numa:    hamiNuma(dev),
 			Health:  dev.Health,
 		}
 		if strings.HasPrefix(device.Type, Ascend910Prefix) {
@@ -95,6 +117,15 @@ func (ps *PluginServer) registerHAMi() error {
 				"NetworkID": networkID,
 			}
 		}
+		// Only faulty devices carry fault details, so that the annotation of
+		// a healthy node stays unchanged.
+		if len(dev.FaultCodes) > 0 || (dev.FaultSeverity != "" && dev.FaultSeverity != manager.FaultSeverityNone) {
+			if device.CustomInfo == nil {
+				device.CustomInfo = map[string]any{}
+			}
+			device.CustomInfo["FaultSeverity"] = string(dev.FaultSeverity)
+			device.CustomInfo["FaultCodes"] = dev.FaultCodeStrings()
+		}
 		apiDevices = append(apiDevices, device)
 	}
 
@@ -114,6 +145,12 @@ func (ps *PluginServer) registerHAMi() error {
 		annos[VNPUNodeSelectorAnnotation] = "false"
 	}
 
+	err = ps.patchNodeAnnotations(annos)
+	ps.debug.recordRegister(annos, err)
+	return err
+}
+
+func (ps *PluginServer) patchNodeAnnotations(annos map[string]string) error {
 	node, err := util.GetNode(ps.nodeName)
 	if err != nil {
 		return fmt.Errorf("get node %s error: %w", ps.nodeName, err)
@@ -143,7 +180,7 @@ func (ps *PluginServer) registerKubelet() error {
 	if ps.registerKubeletFunc != nil {
 		return ps.registerKubeletFunc()
 	}
-	conn, err := ps.dial(v1beta1.KubeletSocket, 5*time.Second)
+	conn, err := ps.dial(ps.kubeletSocket, 5*time.Second)
 	if err != nil {
 		return err
 	}
@@ -156,7 +193,7 @@ func (ps *PluginServer) registerKubelet() error {
 		Endpoint:     path.Base(ps.socket),
 		ResourceName: ps.mgr.ResourceName(),
 		Options: &v1beta1.DevicePluginOptions{
-			GetPreferredAllocationAvailable: false,
+			GetPreferredAllocationAvailable: ps.mgr.PreferredAllocationEnabled(),
 		},
 	}
 
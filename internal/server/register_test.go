@@ -18,12 +18,14 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
 	"github.com/Project-HAMi/HAMi/pkg/util/client"
@@ -172,8 +174,8 @@ func TestRegisterHAMi(t *testing.T) {
 				mgr: &FakeManager{
 					GetDevicesFunc: func() []*manager.Device {
 						return []*manager.Device{
-							{UUID: "uuid1", Memory: 65536, AICore: 60, Health: true},
-							{UUID: "uuid2", Memory: 65536, AICore: 60, Health: false},
+							{UUID: "uuid1", Memory: 65536, AICore: 60, Health: true, NUMANode: 1},
+							{UUID: "uuid2", Memory: 65536, AICore: 60, Health: false, NUMANode: manager.NoNUMANode},
 						}
 					},
 					VDeviceCountFunc: func() int { return 2 },
@@ -196,6 +198,9 @@ func TestRegisterHAMi(t *testing.T) {
 					if devs[1].Health {
 						t.Fatal("device[1] Health = true, want false")
 					}
+					if devs[0].Numa != 1 || devs[1].Numa != 0 {
+						t.Fatalf("device Numa = %d, %d, want 1, 0", devs[0].Numa, devs[1].Numa)
+					}
 					if devs[0].Type != "Ascend910C" {
 						t.Fatalf("device[0] Type = %q, want Ascend910C", devs[0].Type)
 					}
@@ -362,6 +367,41 @@ func TestRegisterHAMi(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "FaultyDevice_CarriesFaultInfo",
+			args: registerHAMiArgs{
+				nodeName:      "test-node",
+				registerAnno:  "hami.io/node-register-Ascend310P",
+				handshakeAnno: "hami.io/node-handshake-Ascend310P",
+				mgr: &FakeManager{
+					GetDevicesFunc: func() []*manager.Device {
+						return []*manager.Device{{
+							UUID: "uuid1", Memory: 16384, AICore: 15, Health: false,
+							FaultCodes: []int64{0x80E01801}, FaultSeverity: manager.FaultSeverityMajor,
+						}}
+					},
+					VDeviceCountFunc:   func() int { return 1 },
+					CommonWordFunc:     func() string { return "Ascend310P" },
+					IsHamiVnpuCoreFunc: func() bool { return false },
+				},
+				nodes: []*v1.Node{
+					{ObjectMeta: metav1.ObjectMeta{Name: "test-node", Annotations: map[string]string{}}},
+				},
+			},
+			want: registerHAMiWant{
+				deviceCount: 1,
+				deviceCheck: func(t *testing.T, devs []*device.DeviceInfo) {
+					t.Helper()
+					if got := devs[0].CustomInfo["FaultSeverity"]; got != "Major" {
+						t.Fatalf("FaultSeverity = %v, want Major", got)
+					}
+					codes, ok := devs[0].CustomInfo["FaultCodes"].([]any)
+					if !ok || len(codes) != 1 || codes[0] != "0x80E01801" {
+						t.Fatalf("FaultCodes = %v, want [0x80E01801]", devs[0].CustomInfo["FaultCodes"])
+					}
+				},
+			},
+		},
 		{
 			name: "IsHamiVnpuCore_True",
 			args: registerHAMiArgs{
@@ -528,3 +568,58 @@ func TestRegisterHAMi(t *testing.T) {
 		})
 	}
 }
+
+// TestWatchAndRegisterOnQueryError checks that a failed device query still
+// registers with HAMi, advertising the chip that failed as unhealthy.
+func TestWatchAndRegisterOnQueryError(t *testing.T) {
+	t.Cleanup(setupFakeClient(nil, []*v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "test-node"}}}))
+	devs := []*manager.Device{{UUID: "uuid1", Health: true}, {UUID: "uuid2", Health: true}}
+	ps := &PluginServer{
+		nodeName:      "test-node",
+		registerAnno:  "hami.io/node-register-Ascend910",
+		handshakeAnno: "hami.io/node-handshake-Ascend910",
+		healthCh:      make(chan struct{}, 1),
+		stopCh:        make(chan interface{}),
+		mgr: &FakeManager{
+			CommonWordFunc:   func() string { return testCommonWord },
+			VDeviceCountFunc: func() int { return 1 },
+			GetDevicesFunc:   func() []*manager.Device { return devs },
+			UpdateDeviceFunc: func() error {
+				devs = []*manager.Device{devs[0], {UUID: "uuid2", Health: false}}
+				return fmt.Errorf("query device 1: dcmi timeout")
+			},
+		},
+	}
+	ps.wg.Add(1)
+	go ps.watchAndRegister()
+	defer func() {
+		close(ps.stopCh)
+		ps.wg.Wait()
+	}()
+
+	var anno string
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true,
+		func(ctx context.Context) (bool, error) {
+			node, err := client.KubeClient.CoreV1().Nodes().Get(ctx, "test-node", metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			anno = node.Annotations[ps.registerAnno]
+			return anno != "", nil
+		})
+	if err != nil {
+		t.Fatalf("no HAMi registration after a failed device query: %v", err)
+	}
+	got, err := device.UnMarshalNodeDevices(anno)
+	if err != nil {
+		t.Fatalf("decode register annotation: %v", err)
+	}
+	if len(got) != 2 || !got[0].Health || got[1].Health {
+		t.Fatalf("registered devices = %+v, want uuid1 healthy and uuid2 unhealthy", got)
+	}
+	select {
+	case <-ps.healthCh:
+	default:
+		t.Error("ListAndWatch was not notified of the unhealthy chip")
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2026 The HAMi Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/klog/v2"
+	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+
+	"github.com/Project-HAMi/ascend-device-plugin/internal"
+)
+
+// configReloadDelay is how long config file events must settle before the
+// config is reloaded.
+const configReloadDelay = time.Second
+
+// RunOptions configures Run.
+type RunOptions struct {
+	// DevicePluginDir is the directory of kubelet's socket. The socket being
+	// created again means kubelet restarted.
+	DevicePluginDir string
+	// ConfigDirs are the directories holding the config files. ConfigMap
+	// volumes update files by swapping a "..data" symlink, which fsnotify
+	// only reports on the directory, not on the file itself.
+	ConfigDirs []string
+	// Reload reloads the config after a change in ConfigDirs and reports
+	// whether the servers must restart to apply it.
+	Reload func() bool
+	// Signals restarts the servers on SIGHUP and stops them on any other
+	// signal.
+	Signals <-chan os.Signal
+}
+
+// Run starts the servers and restarts them whenever kubelet restarts, on
+// SIGHUP, and when a config reload needs it, until another signal arrives.
+// It returns the error of a server that fails to start or to stop.
+func Run(servers []*PluginServer, opts RunOptions) error {
+	klog.Info("Starting FS watcher.")
+	watcher, err := internal.NewFSWatcher(append([]string{opts.DevicePluginDir}, opts.ConfigDirs...)...)
+	if err != nil {
+		return fmt.Errorf("failed to create FS watcher: %v", err)
+	}
+	defer func(watcher *fsnotify.Watcher) {
+		_ = watcher.Close()
+	}(watcher)
+	kubeletSocket := filepath.Join(opts.DevicePluginDir, filepath.Base(v1beta1.KubeletSocket))
+
+	var restarting bool
+	// Config file updates arrive as bursts of events; reload once they settle.
+	var reloadTimeout <-chan time.Time
+restart:
+	if restarting {
+		_ = stopServers(servers)
+	}
+	restarting = true
+	klog.Info("Starting Plugins.")
+	for _, ps := range servers {
+		err = ps.Start()
+		if err != nil {
+			klog.Errorf("Failed to start plugin server: %v", err)
+			_ = stopServers(servers)
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-reloadTimeout:
+			reloadTimeout = nil
+			if opts.Reload != nil && opts.Reload() {
+				klog.Info("config change affects registration, restarting.")
+				goto restart
+			}
+		case event := <-watcher.Events:
+			if event.Name == kubeletSocket && event.Op&fsnotify.Create == fsnotify.Create {
+				klog.Infof("inotify: %s created, restarting.", kubeletSocket)
+				goto restart
+			}
+			if slices.Contains(opts.ConfigDirs, filepath.Dir(event.Name)) && event.Op&fsnotify.Chmod != fsnotify.Chmod {
+				klog.V(4).Infof("inotify: config event %s, reloading.", event)
+				reloadTimeout = time.After(configReloadDelay)
+			}
+		case err := <-watcher.Errors:
+			klog.Errorf("inotify: %s", err)
+		case s := <-opts.Signals:
+			switch s {
+			case syscall.SIGHUP:
+				klog.Info("Received SIGHUP, restarting.")
+				goto restart
+			default:
+				klog.Infof("Received signal \"%v\", shutting down.", s)
+				goto exit
+			}
+		}
+	}
+exit:
+	return stopServers(servers)
+}
+
+// stopServers stops every plugin server and returns the first error.
+func stopServers(servers []*PluginServer) error {
+	var firstErr error
+	for _, ps := range servers {
+		if err := ps.Stop(); err != nil {
+			klog.Errorf("Failed to stop plugin server: %v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -67,10 +67,17 @@ type PluginServer struct {
 	grpcServer            *grpc.Server
 	mgr                   manager.Manager
 	socket                string
+	kubeletSocket         string
 	stopCh                chan interface{}
-	healthCh              chan int32
+	healthCh              chan struct{}
 	checkIdleVNPUInterval int
 	wg                    sync.WaitGroup
+	recorder              EventRecorder
+	checkpoint            *allocationCheckpoint
+	probe                 probeState
+	debug                 debugState
+	cdiSpecDir            string
+	runtimeLess           bool
 
 	// test hooks — injected by tests to avoid real socket/kubelet dependencies
 	dialFunc                 func(unixSocketPath string, timeout time.Duration) (*grpc.ClientConn, error)
@@ -95,16 +102,26 @@ func NewPluginServer(mgr manager.Manager, nodeName string, checkIdleVNPUInterval
 		allocAnno:             fmt.Sprintf("huawei.com/%s", commonWord),
 		toAllocDeviceAnno:     fmt.Sprintf("hami.io/%s-devices-to-allocate", commonWord),
 		mgr:                   mgr,
-		socket:                path.Join(v1beta1.DevicePluginPath, fmt.Sprintf("%s.sock", commonWord)),
 		stopCh:                make(chan interface{}),
-		healthCh:              make(chan int32),
+		healthCh:              make(chan struct{}, 1),
 		checkIdleVNPUInterval: checkIdleVNPUInterval,
 	}
+	server.setDevicePluginDir(v1beta1.DevicePluginPath)
 	// enable calling hami methods
 	device.InRequestDevices[commonWord] = server.toAllocDeviceAnno
 	return server, nil
 }
 
+// setDevicePluginDir places the server's socket and checkpoint in dir and
+// registers with the kubelet socket in it, as kubelet lays out its
+// device-plugins dir.
+func (ps *PluginServer) setDevicePluginDir(dir string) {
+	ps.socket = path.Join(dir, fmt.Sprintf("%s.sock", ps.commonWord))
+	ps.kubeletSocket = path.Join(dir, path.Base(v1beta1.KubeletSocket))
+	ps.checkpoint = newAllocationCheckpoint(
+		path.Join(dir, fmt.Sprintf("hami-ascend-%s.checkpoint", ps.commonWord)), ps.mgr.ResourceName())
+}
+
 // prepareHostResources wraps the package-level prepareHostResources() to
 // allow test injection via the prepareHostResourcesFunc hook.
 func (ps *PluginServer) prepareHostResources() error {
@@ -122,12 +139,26 @@ func (ps *PluginServer) Start() error {
 	}
 
 	ps.stopCh = make(chan interface{})
-	ps.grpcServer = grpc.NewServer()
+	// Stop must wait for the handlers, or a ListAndWatch of this run could
+	// still read stopCh when the next Start replaces it.
+	ps.grpcServer = grpc.NewServer(grpc.WaitForHandlers(true))
 
 	err := ps.mgr.UpdateDevice()
+	ps.probe.deviceUpdated(err)
 	if err != nil {
 		return err
 	}
+	if ps.cdiSpecDir != "" {
+		if err := ps.writeCDISpec(); err != nil {
+			return fmt.Errorf("write CDI spec: %w", err)
+		}
+	}
+	if err := ps.reconcileCheckpoint(context.Background()); err != nil {
+		klog.Errorf("reconcile allocation checkpoint: %v", err)
+	}
+	if err := ps.CleanupIdleVNPUs(); err != nil {
+		klog.Errorf("Failed to cleanup idle vNPUs: %v", err)
+	}
 	if err := ps.enableNodeDeviceShare(); err != nil {
 		return err
 	}
@@ -139,6 +170,8 @@ func (ps *PluginServer) Start() error {
 	if err != nil {
 		return err
 	}
+	kubeletRegistrations.WithLabelValues(ps.mgr.ResourceName()).Inc()
+	ps.probe.setRegistered(true)
 	// Add to the WaitGroup synchronously before launching the goroutines.
 	// sync.WaitGroup requires a positive Add (from a zero counter) to
 	// happen-before Wait; doing Add inside the goroutine races with Stop()'s
@@ -157,6 +190,9 @@ func (ps *PluginServer) startPeriodicCheckIdleVNPUs() {
 	for {
 		select {
 		case <-ticker.C:
+			if err := ps.pruneCheckpoint(context.Background()); err != nil {
+				klog.Errorf("prune allocation checkpoint: %v", err)
+			}
 			klog.Info("Running scheduled idle vNPU cleanup")
 			if err := ps.CleanupIdleVNPUs(); err != nil {
 				klog.Errorf("Failed to cleanup idle vNPUs: %v", err)
@@ -169,6 +205,7 @@ func (ps *PluginServer) startPeriodicCheckIdleVNPUs() {
 }
 
 func (ps *PluginServer) Stop() error {
+	ps.probe.setRegistered(false)
 	if ps.stopCh != nil {
 		select {
 		case <-ps.stopCh:
@@ -190,7 +227,16 @@ func (ps *PluginServer) StopCh() <-chan interface{} {
 }
 
 func (ps *PluginServer) CleanupIdleVNPUs() error {
-	return ps.mgr.CleanupIdleVNPUs()
+	cleaned, err := ps.mgr.CleanupIdleVNPUs()
+	if err != nil {
+		ps.nodeEventf(v1.EventTypeWarning, EventReasonVNPUCleanupFailed, "cleanup of idle %s vNPUs failed: %v", ps.mgr.ResourceName(), err)
+		return err
+	}
+	if cleaned > 0 {
+		vnpusDestroyed.WithLabelValues(ps.mgr.ResourceName()).Add(float64(cleaned))
+		ps.nodeEventf(v1.EventTypeNormal, EventReasonIdleVNPUsCleaned, "destroyed %d idle %s vNPU(s)", cleaned, ps.mgr.ResourceName())
+	}
+	return nil
 }
 
 func (ps *PluginServer) serve() error {
@@ -219,6 +265,7 @@ func (ps *PluginServer) serve() error {
 			}
 
 			klog.Infof("GRPC server for '%s' crashed with error: %v", resourceName, err)
+			grpcServerRestarts.WithLabelValues(resourceName).Inc()
 
 			// restart if it has not been too often
 			// i.e. if server has crashed more than 5 times and it didn't last more than one hour each time
@@ -259,8 +306,9 @@ func (ps *PluginServer) apiDevices() []*v1beta1.Device {
 		}
 		for i := 0; i < vCount; i++ {
 			device := v1beta1.Device{
-				ID:     fmt.Sprintf("%s-%d", dev.UUID, i),
-				Health: health,
+				ID:       fmt.Sprintf("%s-%d", dev.UUID, i),
+				Health:   health,
+				Topology: topologyOf(dev),
 			}
 			devices = append(devices, &device)
 		}
@@ -269,8 +317,19 @@ func (ps *PluginServer) apiDevices() []*v1beta1.Device {
 	return devices
 }
 
+// topologyOf returns the topology hint of dev for the Topology Manager, or nil
+// if dev has no NUMA affinity.
+func topologyOf(dev *manager.Device) *v1beta1.TopologyInfo {
+	if dev.NUMANode == manager.NoNUMANode {
+		return nil
+	}
+	return &v1beta1.TopologyInfo{Nodes: []*v1beta1.NUMANode{{ID: int64(dev.NUMANode)}}}
+}
+
 func (ps *PluginServer) GetDevicePluginOptions(context.Context, *v1beta1.Empty) (*v1beta1.DevicePluginOptions, error) {
-	return &v1beta1.DevicePluginOptions{}, nil
+	return &v1beta1.DevicePluginOptions{
+		GetPreferredAllocationAvailable: ps.mgr.PreferredAllocationEnabled(),
+	}, nil
 }
 
 func (ps *PluginServer) ListAndWatch(e *v1beta1.Empty, s v1beta1.DevicePlugin_ListAndWatchServer) error {
@@ -279,27 +338,37 @@ func (ps *PluginServer) ListAndWatch(e *v1beta1.Empty, s v1beta1.DevicePlugin_Li
 		select {
 		case <-ps.stopCh:
 			return nil
+		case <-s.Context().Done():
+			return nil
 		case <-ps.healthCh:
 			_ = s.Send(&v1beta1.ListAndWatchResponse{Devices: ps.apiDevices()})
 		}
 	}
 }
 
-func (ps *PluginServer) GetPreferredAllocation(context.Context, *v1beta1.PreferredAllocationRequest) (*v1beta1.PreferredAllocationResponse, error) {
-	return nil, fmt.Errorf("not supported")
-}
-
-func (ps *PluginServer) Allocate(ctx context.Context, reqs *v1beta1.AllocateRequest) (*v1beta1.AllocateResponse, error) {
+func (ps *PluginServer) Allocate(ctx context.Context, reqs *v1beta1.AllocateRequest) (allocResp *v1beta1.AllocateResponse, retErr error) {
 	klog.V(5).Infof("Allocate: %v", reqs)
 	success := false
+	reason := ""
 	var pod *v1.Pod
+	// created holds the vNPUs created for all containers of this call, which
+	// are destroyed again if the call fails at any later step.
+	var created []*manager.VNPU
+	start := time.Now()
 	defer func() {
+		resourceName := ps.mgr.ResourceName()
+		allocateRequests.WithLabelValues(resourceName).Inc()
+		allocateDuration.WithLabelValues(resourceName).Observe(time.Since(start).Seconds())
+		if !success {
+			allocateFailures.WithLabelValues(resourceName, reason).Inc()
+		}
+		ps.debug.recordAllocate(start, pod, reqs, allocResp, retErr)
 		if pod == nil {
 			return
 		}
-		if success {
-			ps.podAllocationTrySuccess(pod)
-		} else {
+		if !success {
+			ps.destroyVNPUs(created)
+			ps.podEventf(pod, v1.EventTypeWarning, EventReasonAllocateFailed, "allocate %s failed: %v", ps.mgr.ResourceName(), retErr)
 			ps.podAllocationFailed(pod)
 		}
 	}()
@@ -308,17 +377,20 @@ func (ps *PluginServer) Allocate(ctx context.Context, reqs *v1beta1.AllocateRequ
 	pod, err = util.GetPendingPod(ctx, ps.nodeName)
 	if err != nil {
 		klog.Errorf("get pending pod error: %v", err)
+		reason = allocateReasonPendingPod
 		return nil, fmt.Errorf("get pending pod error: %w", err)
 	}
 	klog.Infof("allocating for pod %s/%s", pod.Namespace, pod.Name)
 
 	rtInfoLookup, err := ps.buildRuntimeInfoLookup(pod)
 	if err != nil {
+		reason = allocateReasonRuntimeInfo
 		return nil, fmt.Errorf("build runtimeInfo lookup: %w", err)
 	}
 
 	podSingleDev, err := ps.decodeDeviceAnnotations(pod)
 	if err != nil {
+		reason = allocateReasonDecode
 		return nil, fmt.Errorf("decode device annotations: %w", err)
 	}
 
@@ -326,31 +398,49 @@ func (ps *PluginServer) Allocate(ctx context.Context, reqs *v1beta1.AllocateRequ
 	// a subset of containers. Use pop semantics to match each request with its
 	// corresponding containerDevices.
 	responses := v1beta1.AllocateResponse{}
+	allocs := make([]ContainerAllocation, 0, len(reqs.ContainerRequests))
 	for _, req := range reqs.ContainerRequests {
 		containerDevs, ctrName, err := ps.popNextContainerDevices(pod, podSingleDev)
 		if err != nil {
+			reason = allocateReasonNoContainer
 			return nil, fmt.Errorf("get next container devices: %w", err)
 		}
 		klog.Infof("containerDevs: %+v", containerDevs)
 
 		if len(containerDevs) != len(req.DevicesIds) {
+			reason = allocateReasonCountMismatch
 			return nil, fmt.Errorf("device number not matched: annotation has %d, request has %d", len(containerDevs), len(req.DevicesIds))
 		}
 
-		resp, err := ps.buildContainerAllocateResponse(pod, ctrName, containerDevs, rtInfoLookup)
+		resp, alloc, err := ps.buildContainerAllocation(pod, ctrName, containerDevs, rtInfoLookup)
 		if err != nil {
+			reason = allocateReasonBuildResponse
 			return nil, fmt.Errorf("build container allocate response: %w", err)
 		}
+		for _, d := range alloc.Devices {
+			if v := vnpuOf(*alloc, d); v != nil {
+				created = append(created, v)
+			}
+		}
 		responses.ContainerResponses = append(responses.ContainerResponses, resp)
+		allocs = append(allocs, *alloc)
 	}
 
 	// Patch the annotation with the in-memory erased podSingleDev.
 	if err := ps.patchErasedAnnotation(pod, podSingleDev); err != nil {
 		klog.Errorf("erase allocated containers annotation error: %v", err)
+		reason = allocateReasonPatchAnnotation
 		return nil, fmt.Errorf("erase allocated containers annotation: %w", err)
 	}
 
+	if err := ps.podAllocationTrySuccess(pod, podSingleDev); err != nil {
+		klog.Errorf("set bind phase error: %v", err)
+		reason = allocateReasonBindPhase
+		return nil, err
+	}
+
 	klog.V(5).Infof("allocate response: %+v", responses.ContainerResponses)
+	ps.recordAllocations(allocs)
 	success = true
 	return &responses, nil
 }
@@ -22,13 +22,17 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"reflect"
 	"strings"
 	"testing"
 
 	"google.golang.org/grpc/grpclog"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
@@ -143,6 +147,8 @@ func TestAllocate(t *testing.T) {
 	type allocateWant struct {
 		containerResponses []*v1beta1.ContainerAllocateResponse
 		nodeLockReleased   bool
+		// allocateFailedEvent expects a Warning AllocateFailed event on the pod.
+		allocateFailedEvent bool
 	}
 
 	tests := []struct {
@@ -236,6 +242,7 @@ func TestAllocate(t *testing.T) {
 					},
 				},
 			},
+			want:    allocateWant{allocateFailedEvent: true},
 			wantErr: "device number not matched",
 			setup: func() CleanupFunc {
 				c1 := setupInRequestDevices("Ascend910")
@@ -330,6 +337,7 @@ func TestAllocate(t *testing.T) {
 					},
 				},
 			},
+			want:    allocateWant{allocateFailedEvent: true},
 			wantErr: "unknown uuid",
 			setup: func() CleanupFunc {
 				c1 := setupInRequestDevices("Ascend910")
@@ -366,7 +374,8 @@ func TestAllocate(t *testing.T) {
 				},
 			},
 			want: allocateWant{
-				nodeLockReleased: true,
+				nodeLockReleased:    true,
+				allocateFailedEvent: true,
 			},
 			wantErr: "annotation",
 			setup: func() CleanupFunc {
@@ -388,6 +397,8 @@ func TestAllocate(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Cleanup(tc.setup())
+			recorder := record.NewFakeRecorder(10)
+			tc.args.ps.recorder = recorder
 
 			resp, err := tc.args.ps.Allocate(context.Background(), tc.args.reqs)
 
@@ -419,6 +430,20 @@ func TestAllocate(t *testing.T) {
 				}
 			}
 
+			// Check the pod event
+			close(recorder.Events)
+			var events []string
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			if tc.want.allocateFailedEvent {
+				if len(events) != 1 || !strings.HasPrefix(events[0], "Warning AllocateFailed ") || !strings.Contains(events[0], tc.wantErr) {
+					t.Fatalf("events = %q, want one Warning AllocateFailed containing %q", events, tc.wantErr)
+				}
+			} else if len(events) != 0 {
+				t.Fatalf("unexpected events: %q", events)
+			}
+
 			// Check node lock state
 			if tc.want.nodeLockReleased && tc.args.ps.nodeName != "missing-node" {
 				updatedNode, nErr := client.KubeClient.CoreV1().Nodes().Get(context.Background(), tc.args.ps.nodeName, metav1.GetOptions{})
@@ -433,6 +458,96 @@ func TestAllocate(t *testing.T) {
 	}
 }
 
+// TestAllocateRollsBackVNPUs checks that a failed Allocate destroys the vNPUs
+// it created for every container of the call, whatever step failed.
+func TestAllocateRollsBackVNPUs(t *testing.T) {
+	tests := []struct {
+		name string
+		// failCreate makes CreateVNPU fail for this device.
+		failCreate string
+		// failPatch makes the n-th pod patch fail: 1 is the annotation
+		// erase, 2 the bind phase.
+		failPatch int
+		wantErr   string
+	}{
+		{name: "SecondContainerCreateFails", failCreate: "uuid2", wantErr: "create vNPU"},
+		{name: "PatchAnnotationFails", failPatch: 1, wantErr: "erase allocated containers annotation"},
+		{name: "BindPhaseFails", failPatch: 2, wantErr: "set bind phase"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(setupInRequestDevices(testCommonWord))
+			rtData, _ := json.Marshal([]ascend.RuntimeInfo{
+				{UUID: "uuid1", Temp: "vir01"},
+				{UUID: "uuid2", Temp: "vir01"},
+			})
+			_, _, cleanup := setupAllocateEnv("test-node", "test-pod", "default", 2, map[string]string{
+				"hami.io/Ascend910-devices-to-allocate": device.EncodePodSingleDevice(device.PodSingleDevice{
+					{cd("uuid1", testCommonWord, 1024, 4)},
+					{cd("uuid2", testCommonWord, 1024, 4)},
+				}),
+				"huawei.com/Ascend910":   string(rtData),
+				util.BindTimeAnnotations: "2024-01-01T00:00:00Z",
+				util.DeviceBindPhase:     util.DeviceBindAllocating,
+			})
+			t.Cleanup(cleanup)
+			patches := 0
+			client.KubeClient.(*fake.Clientset).PrependReactor("patch", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+				patches++
+				if patches == tc.failPatch {
+					return true, nil, fmt.Errorf("patch failed")
+				}
+				return false, nil, nil
+			})
+
+			live := map[uint32]bool{}
+			nextID := uint32(100)
+			ps := &PluginServer{
+				commonWord:        testCommonWord,
+				nodeName:          "test-node",
+				toAllocDeviceAnno: "hami.io/Ascend910-devices-to-allocate",
+				allocAnno:         "huawei.com/Ascend910",
+				recorder:          record.NewFakeRecorder(10),
+				mgr: &FakeManager{
+					GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+						return &manager.Device{UUID: uuid, PhyID: map[string]int32{"uuid1": 0, "uuid2": 1}[uuid]}
+					},
+					CreateVNPUEnabledFunc: func() bool { return true },
+					CreateVNPUFunc: func(uuid, template, owner string) (*manager.VNPU, error) {
+						if uuid == tc.failCreate {
+							return nil, fmt.Errorf("no free AI cores")
+						}
+						nextID++
+						live[nextID] = true
+						return &manager.VNPU{UUID: uuid, VDevID: nextID, Template: template, Owner: owner}, nil
+					},
+					DestroyVNPUFunc: func(v *manager.VNPU) error {
+						delete(live, v.VDevID)
+						return nil
+					},
+				},
+			}
+
+			_, err := ps.Allocate(context.Background(), &v1beta1.AllocateRequest{
+				ContainerRequests: []*v1beta1.ContainerAllocateRequest{
+					{DevicesIds: []string{"uuid1-0"}},
+					{DevicesIds: []string{"uuid2-0"}},
+				},
+			})
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("Allocate() error = %v, want %q", err, tc.wantErr)
+			}
+			if nextID == 100 {
+				t.Fatal("no vNPU was created")
+			}
+			if len(live) != 0 {
+				t.Errorf("vNPUs %v survived the failed Allocate", live)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // NewPluginServer tests
 // ============================================================================
@@ -589,6 +704,9 @@ func TestNewPluginServer_RegistersInRequestDevices(t *testing.T) {
 // ============================================================================
 
 func TestApiDevices(t *testing.T) {
+	// Devices default to NUMA node 0.
+	numaNode0 := &v1beta1.TopologyInfo{Nodes: []*v1beta1.NUMANode{{ID: 0}}}
+
 	type apiDevicesArgs struct {
 		mgr *FakeManager
 	}
@@ -619,7 +737,7 @@ func TestApiDevices(t *testing.T) {
 				},
 			},
 			want: []*v1beta1.Device{
-				{ID: "uuid1-0", Health: v1beta1.Healthy},
+				{ID: "uuid1-0", Health: v1beta1.Healthy, Topology: numaNode0},
 			},
 		},
 		{
@@ -633,9 +751,9 @@ func TestApiDevices(t *testing.T) {
 				},
 			},
 			want: []*v1beta1.Device{
-				{ID: "uuid1-0", Health: v1beta1.Healthy},
-				{ID: "uuid1-1", Health: v1beta1.Healthy},
-				{ID: "uuid1-2", Health: v1beta1.Healthy},
+				{ID: "uuid1-0", Health: v1beta1.Healthy, Topology: numaNode0},
+				{ID: "uuid1-1", Health: v1beta1.Healthy, Topology: numaNode0},
+				{ID: "uuid1-2", Health: v1beta1.Healthy, Topology: numaNode0},
 			},
 		},
 		{
@@ -649,7 +767,7 @@ func TestApiDevices(t *testing.T) {
 				},
 			},
 			want: []*v1beta1.Device{
-				{ID: "uuid1-0", Health: v1beta1.Unhealthy},
+				{ID: "uuid1-0", Health: v1beta1.Unhealthy, Topology: numaNode0},
 			},
 		},
 		{
@@ -666,10 +784,10 @@ func TestApiDevices(t *testing.T) {
 				},
 			},
 			want: []*v1beta1.Device{
-				{ID: "uuid1-0", Health: v1beta1.Healthy},
-				{ID: "uuid1-1", Health: v1beta1.Healthy},
-				{ID: "uuid2-0", Health: v1beta1.Unhealthy},
-				{ID: "uuid2-1", Health: v1beta1.Unhealthy},
+				{ID: "uuid1-0", Health: v1beta1.Healthy, Topology: numaNode0},
+				{ID: "uuid1-1", Health: v1beta1.Healthy, Topology: numaNode0},
+				{ID: "uuid2-0", Health: v1beta1.Unhealthy, Topology: numaNode0},
+				{ID: "uuid2-1", Health: v1beta1.Unhealthy, Topology: numaNode0},
 			},
 		},
 		{
@@ -684,6 +802,24 @@ func TestApiDevices(t *testing.T) {
 			},
 			want: nil,
 		},
+		{
+			name: "NUMAAffinity",
+			args: apiDevicesArgs{
+				mgr: &FakeManager{
+					GetDevicesFunc: func() []*manager.Device {
+						return []*manager.Device{
+							{UUID: "uuid1", Health: true, NUMANode: 1},
+							{UUID: "uuid2", Health: true, NUMANode: manager.NoNUMANode},
+						}
+					},
+					VDeviceCountFunc: func() int { return 1 },
+				},
+			},
+			want: []*v1beta1.Device{
+				{ID: "uuid1-0", Health: v1beta1.Healthy, Topology: &v1beta1.TopologyInfo{Nodes: []*v1beta1.NUMANode{{ID: 1}}}},
+				{ID: "uuid2-0", Health: v1beta1.Healthy},
+			},
+		},
 		{
 			name: "EmptySliceDeviceList",
 			args: apiDevicesArgs{
@@ -711,6 +847,9 @@ func TestApiDevices(t *testing.T) {
 				if got[i].Health != wantDev.Health {
 					t.Errorf("device[%d].Health = %v, want %v", i, got[i].Health, wantDev.Health)
 				}
+				if got[i].GetTopology().String() != wantDev.GetTopology().String() {
+					t.Errorf("device[%d].Topology = %v, want %v", i, got[i].GetTopology(), wantDev.GetTopology())
+				}
 			}
 		})
 	}
@@ -726,15 +865,16 @@ func TestCleanupIdleVNPUs(t *testing.T) {
 	}
 
 	tests := []struct {
-		name    string
-		args    cleanupIdleVNPUsArgs
-		wantErr bool
+		name       string
+		args       cleanupIdleVNPUsArgs
+		wantErr    bool
+		wantEvents []string
 	}{
 		{
 			name: "DelegatesToManager",
 			args: cleanupIdleVNPUsArgs{
 				mgr: &FakeManager{
-					CleanupIdleVNPUsFunc: func() error { return nil },
+					CleanupIdleVNPUsFunc: func() (int, error) { return 0, nil },
 				},
 			},
 		},
@@ -742,10 +882,22 @@ func TestCleanupIdleVNPUs(t *testing.T) {
 			name: "ReturnsManagerError",
 			args: cleanupIdleVNPUsArgs{
 				mgr: &FakeManager{
-					CleanupIdleVNPUsFunc: func() error { return fmt.Errorf("cleanup failed") },
+					ResourceNameFunc:     func() string { return "huawei.com/Ascend310P" },
+					CleanupIdleVNPUsFunc: func() (int, error) { return 0, fmt.Errorf("cleanup failed") },
 				},
 			},
-			wantErr: true,
+			wantErr:    true,
+			wantEvents: []string{"Warning VNPUCleanupFailed cleanup of idle huawei.com/Ascend310P vNPUs failed: cleanup failed"},
+		},
+		{
+			name: "DestroyedVNPUsPostNodeEvent",
+			args: cleanupIdleVNPUsArgs{
+				mgr: &FakeManager{
+					ResourceNameFunc:     func() string { return "huawei.com/Ascend310P" },
+					CleanupIdleVNPUsFunc: func() (int, error) { return 2, nil },
+				},
+			},
+			wantEvents: []string{"Normal IdleVNPUsCleaned destroyed 2 idle huawei.com/Ascend310P vNPU(s)"},
 		},
 		{
 			name: "NilFuncReturnsNil",
@@ -757,11 +909,20 @@ func TestCleanupIdleVNPUs(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			ps := &PluginServer{mgr: tc.args.mgr}
+			recorder := record.NewFakeRecorder(10)
+			ps := &PluginServer{mgr: tc.args.mgr, recorder: recorder}
 			err := ps.CleanupIdleVNPUs()
 			if (err != nil) != tc.wantErr {
 				t.Fatalf("CleanupIdleVNPUs() error = %v, wantErr %v", err, tc.wantErr)
 			}
+			close(recorder.Events)
+			var got []string
+			for e := range recorder.Events {
+				got = append(got, e)
+			}
+			if !reflect.DeepEqual(got, tc.wantEvents) {
+				t.Fatalf("events = %q, want %q", got, tc.wantEvents)
+			}
 		})
 	}
 }
@@ -833,7 +994,7 @@ func setupRestartablePluginServer(t *testing.T) *PluginServer {
 		mgr:                   &FakeManager{ResourceNameFunc: func() string { return "test-ascend" }},
 		socket:                path.Join(t.TempDir(), "test-ascend.sock"),
 		stopCh:                make(chan interface{}),
-		healthCh:              make(chan int32),
+		healthCh:              make(chan struct{}, 1),
 		checkIdleVNPUInterval: 3600,
 		dialFunc:              nil,
 		registerKubeletFunc: func() error {
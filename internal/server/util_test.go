@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -14,6 +15,7 @@ import (
 	"k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
 
 	"github.com/Project-HAMi/HAMi/pkg/device"
+	"github.com/Project-HAMi/ascend-device-plugin/internal"
 	"github.com/Project-HAMi/ascend-device-plugin/internal/manager"
 )
 
@@ -211,8 +213,10 @@ func TestBuildContainerAllocateResponse(t *testing.T) {
 	}
 
 	type buildContainerAllocateResponseWant struct {
-		envs   map[string]string
-		mounts []*v1beta1.Mount
+		envs       map[string]string
+		mounts     []*v1beta1.Mount
+		cdiDevices []string
+		devices    []string
 	}
 
 	tests := []struct {
@@ -358,6 +362,54 @@ func TestBuildContainerAllocateResponse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "HamiCoreMode_CustomProfile",
+			setup: func() (*PluginServer, CleanupFunc) {
+				return &PluginServer{
+					mgr: &FakeManager{
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: "uuid1", PhyID: 3}
+						},
+						ContainerProfileFunc: func() internal.ContainerProfile {
+							return internal.ResolveContainerProfile(&internal.ContainerProfile{
+								DriverMounts: []internal.Mount{{HostPath: "/opt/ascend/driver", ContainerPath: "/usr/local/Ascend/driver", ReadOnly: true}},
+								Mounts:       []internal.Mount{{HostPath: "/var/log/npu/{podUID}", ContainerPath: "/var/log/npu"}},
+								Env:          map[string]string{"NPU_GLOBAL_SHM_PATH": "/shm/{deviceID}"},
+								Preload:      []string{"/hami-vnpu-core/libvnpu.so", "/opt/ascend/libdcmi.so"},
+							})
+						},
+					},
+					allocAnno: allocAnno,
+				}, func() {
+					data, err := os.ReadFile(hostHookPath + "/containers/uid1_/ld.so.preload")
+					if err != nil || string(data) != "/hami-vnpu-core/libvnpu.so\n/opt/ascend/libdcmi.so\n" {
+						t.Errorf("ld.so.preload = %q, %v", data, err)
+					}
+				}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod: &v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						UID:         "uid1",
+						Annotations: map[string]string{VNPUModeAnnotation: VNPUModeHamiCore},
+					},
+				},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend910", 1024, 4)},
+				rtInfoLookup:  map[string]RuntimeInfo{},
+			},
+			want: buildContainerAllocateResponseWant{
+				envs: map[string]string{
+					"ASCEND_VISIBLE_DEVICES": "3",
+					"NPU_GLOBAL_SHM_PATH":    "/shm/3",
+				},
+				mounts: []*v1beta1.Mount{
+					{HostPath: "/opt/ascend/driver", ContainerPath: "/usr/local/Ascend/driver", ReadOnly: true},
+					{HostPath: "/var/log/npu/uid1", ContainerPath: "/var/log/npu", ReadOnly: false},
+					{HostPath: hostHookPath + "/containers/uid1_", ContainerPath: "/hami-vnpu-shmem", ReadOnly: false},
+					{HostPath: hostHookPath + "/containers/uid1_/ld.so.preload", ContainerPath: "/etc/ld.so.preload", ReadOnly: true},
+				},
+			},
+		},
 		{
 			name: "HamiCoreMode_NilMemoryCore",
 			setup: func() (*PluginServer, CleanupFunc) {
@@ -614,6 +666,279 @@ func TestBuildContainerAllocateResponse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "CDIWholeCard",
+			setup: func() (*PluginServer, CleanupFunc) {
+				return &PluginServer{
+					mgr: &FakeManager{
+						ResourceNameFunc: func() string { return "huawei.com/Ascend910" },
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: uuid, PhyID: map[string]int32{"uuid1": 0, "uuid2": 1}[uuid]}
+						},
+					},
+					allocAnno:  allocAnno,
+					cdiSpecDir: t.TempDir(),
+				}, func() {}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod:           &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend910", 1024, 4), cd("uuid2", "Ascend910", 1024, 4)},
+				rtInfoLookup:  map[string]RuntimeInfo{},
+			},
+			want: buildContainerAllocateResponseWant{
+				envs:       map[string]string{"ASCEND_VISIBLE_DEVICES": "0,1"},
+				cdiDevices: []string{"huawei.com/Ascend910=0", "huawei.com/Ascend910=1"},
+			},
+		},
+		{
+			name: "CDIHamiCore",
+			setup: func() (*PluginServer, CleanupFunc) {
+				return &PluginServer{
+					mgr: &FakeManager{
+						ResourceNameFunc: func() string { return "huawei.com/Ascend910" },
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: "uuid1", PhyID: 3}
+						},
+					},
+					allocAnno:  allocAnno,
+					cdiSpecDir: t.TempDir(),
+				}, func() {}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod: &v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{VNPUModeAnnotation: VNPUModeHamiCore},
+					},
+				},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend910", 1024, 4)},
+				rtInfoLookup:  map[string]RuntimeInfo{},
+			},
+			want: buildContainerAllocateResponseWant{
+				envs: map[string]string{
+					"ASCEND_VISIBLE_DEVICES": "3",
+					"NPU_GLOBAL_SHM_PATH":    "/hami-shared-region/3_global_registry",
+				},
+				// The static mounts come with the CDI devices.
+				mounts: []*v1beta1.Mount{
+					{HostPath: hostHookPath + "/containers/_", ContainerPath: "/hami-vnpu-shmem", ReadOnly: false},
+				},
+				cdiDevices: []string{"huawei.com/Ascend910=3", "huawei.com/Ascend910=" + CDISoftSliceDevice},
+			},
+		},
+		{
+			name: "CDITemplateVNPULeftToRuntime",
+			setup: func() (*PluginServer, CleanupFunc) {
+				return &PluginServer{
+					mgr: &FakeManager{
+						ResourceNameFunc: func() string { return "huawei.com/Ascend910" },
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: "uuid1", PhyID: 3}
+						},
+					},
+					allocAnno:  allocAnno,
+					cdiSpecDir: t.TempDir(),
+				}, func() {}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod:           &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend910", 1024, 4)},
+				rtInfoLookup: map[string]RuntimeInfo{
+					"uuid1": {UUID: "uuid1", Temp: "vir02"},
+				},
+			},
+			want: buildContainerAllocateResponseWant{
+				envs: map[string]string{
+					"ASCEND_VISIBLE_DEVICES": "3",
+					"ASCEND_VNPU_SPECS":      "vir02",
+				},
+			},
+		},
+		{
+			name: "RuntimeLessWholeCard",
+			setup: func() (*PluginServer, CleanupFunc) {
+				return &PluginServer{
+					mgr: &FakeManager{
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: uuid, PhyID: map[string]int32{"uuid1": 0, "uuid2": 1}[uuid]}
+						},
+					},
+					allocAnno:   allocAnno,
+					runtimeLess: true,
+				}, func() {}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod:           &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend910", 1024, 4), cd("uuid2", "Ascend910", 1024, 4)},
+				rtInfoLookup:  map[string]RuntimeInfo{},
+			},
+			want: buildContainerAllocateResponseWant{
+				envs: map[string]string{"ASCEND_VISIBLE_DEVICES": "0,1"},
+				mounts: []*v1beta1.Mount{
+					{HostPath: "/usr/local/bin/npu-smi", ContainerPath: "/usr/local/bin/npu-smi", ReadOnly: true},
+					{HostPath: "/etc/ascend_install.info", ContainerPath: "/etc/ascend_install.info", ReadOnly: true},
+					{HostPath: "/usr/local/Ascend/driver/lib64/driver", ContainerPath: "/usr/local/Ascend/driver/lib64/driver", ReadOnly: true},
+					{HostPath: "/usr/local/Ascend/driver/version.info", ContainerPath: "/usr/local/Ascend/driver/version.info", ReadOnly: true},
+					{HostPath: "/usr/local/Ascend/driver/lib64/common", ContainerPath: "/usr/local/Ascend/driver/lib64/common", ReadOnly: true},
+					{HostPath: "/usr/local/dcmi", ContainerPath: "/usr/local/dcmi", ReadOnly: true},
+				},
+				devices: []string{"/dev/davinci0", "/dev/davinci1", "/dev/davinci_manager", "/dev/devmm_svm", "/dev/hisi_hdc"},
+			},
+		},
+		{
+			name: "RuntimeLessCreateVNPU",
+			setup: func() (*PluginServer, CleanupFunc) {
+				return &PluginServer{
+					mgr: &FakeManager{
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: uuid, PhyID: 3}
+						},
+						CreateVNPUEnabledFunc: func() bool { return true },
+						CreateVNPUFunc: func(uuid, template, owner string) (*manager.VNPU, error) {
+							return &manager.VNPU{UUID: uuid, VDevID: 100, Template: template, Owner: owner}, nil
+						},
+					},
+					allocAnno:   allocAnno,
+					runtimeLess: true,
+				}, func() {}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod:           &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default", Annotations: map[string]string{}}},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend310P", 6144, 2)},
+				rtInfoLookup: map[string]RuntimeInfo{
+					"uuid1": {UUID: "uuid1", Temp: "vir02"},
+				},
+			},
+			want: buildContainerAllocateResponseWant{
+				envs: map[string]string{"ASCEND_VISIBLE_DEVICES": "100"},
+				mounts: []*v1beta1.Mount{
+					{HostPath: "/usr/local/bin/npu-smi", ContainerPath: "/usr/local/bin/npu-smi", ReadOnly: true},
+					{HostPath: "/etc/ascend_install.info", ContainerPath: "/etc/ascend_install.info", ReadOnly: true},
+					{HostPath: "/usr/local/Ascend/driver/lib64/driver", ContainerPath: "/usr/local/Ascend/driver/lib64/driver", ReadOnly: true},
+					{HostPath: "/usr/local/Ascend/driver/version.info", ContainerPath: "/usr/local/Ascend/driver/version.info", ReadOnly: true},
+					{HostPath: "/usr/local/Ascend/driver/lib64/common", ContainerPath: "/usr/local/Ascend/driver/lib64/common", ReadOnly: true},
+					{HostPath: "/usr/local/dcmi", ContainerPath: "/usr/local/dcmi", ReadOnly: true},
+				},
+				devices: []string{"/dev/vdavinci100", "/dev/davinci_manager", "/dev/devmm_svm", "/dev/hisi_hdc"},
+			},
+		},
+		{
+			name: "RuntimeLessTemplateVNPUWithoutCreateVNPU",
+			setup: func() (*PluginServer, CleanupFunc) {
+				return &PluginServer{
+					mgr: &FakeManager{
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: uuid, PhyID: 3}
+						},
+					},
+					allocAnno:   allocAnno,
+					runtimeLess: true,
+				}, func() {}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod:           &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend910", 1024, 4)},
+				rtInfoLookup: map[string]RuntimeInfo{
+					"uuid1": {UUID: "uuid1", Temp: "vir02"},
+				},
+			},
+			wantErr: "needs vnpus.createVNPU",
+		},
+		{
+			// CDI does not bring template vNPUs either, so runtime-less
+			// mode must still reject them.
+			name: "RuntimeLessCDITemplateVNPUWithoutCreateVNPU",
+			setup: func() (*PluginServer, CleanupFunc) {
+				return &PluginServer{
+					mgr: &FakeManager{
+						ResourceNameFunc: func() string { return "huawei.com/Ascend910" },
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: uuid, PhyID: 3}
+						},
+					},
+					allocAnno:   allocAnno,
+					cdiSpecDir:  t.TempDir(),
+					runtimeLess: true,
+				}, func() {}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod:           &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend910", 1024, 4)},
+				rtInfoLookup: map[string]RuntimeInfo{
+					"uuid1": {UUID: "uuid1", Temp: "vir02"},
+				},
+			},
+			wantErr: "needs vnpus.createVNPU",
+		},
+		{
+			name: "CreateVNPUMode",
+			setup: func() (*PluginServer, CleanupFunc) {
+				return &PluginServer{
+					mgr: &FakeManager{
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: uuid, PhyID: 3}
+						},
+						CreateVNPUEnabledFunc: func() bool { return true },
+						CreateVNPUFunc: func(uuid, template, owner string) (*manager.VNPU, error) {
+							if template != "vir02" || owner != "default/p1/" {
+								return nil, fmt.Errorf("unexpected template %q owner %q", template, owner)
+							}
+							return &manager.VNPU{UUID: uuid, VDevID: 100, Template: template, Owner: owner}, nil
+						},
+					},
+					allocAnno: allocAnno,
+				}, func() {}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod:           &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default", Annotations: map[string]string{}}},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend310P", 6144, 2)},
+				rtInfoLookup: map[string]RuntimeInfo{
+					"uuid1": {UUID: "uuid1", Temp: "vir02"},
+				},
+			},
+			want: buildContainerAllocateResponseWant{
+				envs: map[string]string{
+					"ASCEND_VISIBLE_DEVICES": "100",
+				},
+			},
+		},
+		{
+			name: "CreateVNPUModeRollsBackOnFailure",
+			setup: func() (*PluginServer, CleanupFunc) {
+				var destroyed []uint32
+				return &PluginServer{
+					mgr: &FakeManager{
+						GetDeviceByUUIDFunc: func(uuid string) *manager.Device {
+							return &manager.Device{UUID: uuid}
+						},
+						CreateVNPUEnabledFunc: func() bool { return true },
+						CreateVNPUFunc: func(uuid, template, owner string) (*manager.VNPU, error) {
+							if uuid == "uuid2" {
+								return nil, errors.New("no free AI core")
+							}
+							return &manager.VNPU{UUID: uuid, VDevID: 101}, nil
+						},
+						DestroyVNPUFunc: func(vnpu *manager.VNPU) error {
+							destroyed = append(destroyed, vnpu.VDevID)
+							return nil
+						},
+					},
+					allocAnno: allocAnno,
+				}, func() {
+					if len(destroyed) != 1 || destroyed[0] != 101 {
+						t.Errorf("destroyed vNPUs = %v, want [101]", destroyed)
+					}
+				}
+			},
+			args: buildContainerAllocateResponseArgs{
+				pod:           &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "default", Annotations: map[string]string{}}},
+				containerDevs: device.ContainerDevices{cd("uuid1", "Ascend310P", 6144, 2), cd("uuid2", "Ascend310P", 6144, 2)},
+				rtInfoLookup: map[string]RuntimeInfo{
+					"uuid1": {UUID: "uuid1", Temp: "vir02"},
+					"uuid2": {UUID: "uuid2", Temp: "vir02"},
+				},
+			},
+			wantErr: "no free AI core",
+		},
 	}
 
 	for _, tc := range tests {
@@ -669,14 +994,32 @@ func TestBuildContainerAllocateResponse(t *testing.T) {
 				}
 			}
 
-			// Non-hami-core mode: Mounts and Devices should be nil
+			// Check CDI devices
+			var gotCDI []string
+			for _, d := range resp.CdiDevices {
+				gotCDI = append(gotCDI, d.Name)
+			}
+			if !reflect.DeepEqual(gotCDI, tc.want.cdiDevices) {
+				t.Errorf("CDI devices = %v, want %v", gotCDI, tc.want.cdiDevices)
+			}
+
+			// Check device nodes
+			var gotDevices []string
+			for _, d := range resp.Devices {
+				if d.HostPath != d.ContainerPath || d.Permissions != "rw" {
+					t.Errorf("device %+v should map its host path read-write", d)
+				}
+				gotDevices = append(gotDevices, d.ContainerPath)
+			}
+			if !reflect.DeepEqual(gotDevices, tc.want.devices) {
+				t.Errorf("devices = %v, want %v", gotDevices, tc.want.devices)
+			}
+
+			// Non-hami-core mode: Mounts should be nil unless expected
 			if tc.want.mounts == nil && tc.args.pod.Annotations[VNPUModeAnnotation] != VNPUModeHamiCore {
 				if resp.Mounts != nil {
 					t.Fatal("resp.Mounts should be nil in non-hami-core mode")
 				}
-				if resp.Devices != nil {
-					t.Fatal("resp.Devices should be nil")
-				}
 			}
 		})
 	}
@@ -0,0 +1,436 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulated provides an in-memory NPU backend, so that the plugin
+// runs end to end on nodes without Ascend hardware, e.g. for demos or for
+// testing HAMi scheduling on CPU-only clusters.
+package simulated
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"ascend-common/devmanager"
+	"ascend-common/devmanager/common"
+	"ascend-common/devmanager/dcmi"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// firstVDevID is the ID of the first vNPU the backend creates.
+const firstVDevID = 100
+
+// Config describes the simulated NPUs of a node.
+type Config struct {
+	Cards        []Card       `json:"cards"`
+	HealthScript HealthScript `json:"healthScript,omitempty"`
+}
+
+// Card is an NPU card holding one or more chips.
+type Card struct {
+	CardID int32  `json:"cardID"`
+	Chips  []Chip `json:"chips"`
+}
+
+// Chip is one simulated NPU. LogicID and PhyID default to the position of
+// the chip on the node, DeviceID to its position on the card.
+type Chip struct {
+	LogicID     *int32 `json:"logicID,omitempty"`
+	PhyID       *int32 `json:"phyID,omitempty"`
+	DeviceID    *int32 `json:"deviceID,omitempty"`
+	Name        string `json:"name"`
+	UUID        string `json:"uuid"`
+	ProductType string `json:"productType,omitempty"`
+	BusID       string `json:"busID,omitempty"`
+	// Memory is the memory size of the chip in MB.
+	Memory     uint64  `json:"memory,omitempty"`
+	Health     uint32  `json:"health,omitempty"`
+	ErrorCodes []int64 `json:"errorCodes,omitempty"`
+	// VNPUs are the vNPUs that exist on the chip when the backend starts.
+	VNPUs []VNPU `json:"vnpus,omitempty"`
+}
+
+// VNPU is a vNPU existing on a chip when the backend starts.
+type VNPU struct {
+	Template string `json:"template"`
+	InUse    bool   `json:"inUse,omitempty"`
+}
+
+// HealthScript changes the health of chips over time.
+type HealthScript struct {
+	Steps []HealthStep `json:"steps,omitempty"`
+	// RepeatEvery restarts the script from the configured health of the
+	// chips at this period. The script runs once when unset.
+	RepeatEvery *metav1.Duration `json:"repeatEvery,omitempty"`
+}
+
+// HealthStep sets the health and error codes of the chip with the given
+// UUID once After has passed since the backend started.
+type HealthStep struct {
+	After      metav1.Duration `json:"after"`
+	UUID       string          `json:"uuid"`
+	Health     uint32          `json:"health"`
+	ErrorCodes []int64         `json:"errorCodes,omitempty"`
+}
+
+// chip is a chip of the backend with its defaults applied.
+type chip struct {
+	Chip
+	cardID, logicID, phyID, deviceID int32
+}
+
+// health is the health state of a chip.
+type health struct {
+	health uint32
+	codes  []int64
+}
+
+// ErrNotSimulated is returned by the devmanager.DeviceInterface methods the
+// backend does not simulate, e.g. telemetry.
+var ErrNotSimulated = errors.New("not supported by simulated NPUs")
+
+// Backend implements devmanager.DeviceInterface on top of a Config. Methods
+// without simulated data return ErrNotSimulated. The embedded interface only
+// covers methods of newer ascend-common versions that the plugin never calls.
+type Backend struct {
+	devmanager.DeviceInterface
+
+	chips  map[int32]*chip
+	order  []int32
+	uuids  map[string]int32
+	script HealthScript
+	start  time.Time
+	now    func() time.Time
+
+	mu         sync.Mutex
+	vdevs      map[int32][]common.CgoVDevQueryStru
+	nextVDevID uint32
+}
+
+// Load reads a Config from the YAML file at path and returns its backend.
+func Load(path string) (*Backend, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return New(cfg)
+}
+
+// New returns the backend of cfg. Its health script starts now.
+func New(cfg Config) (*Backend, error) {
+	return newBackend(cfg, time.Now)
+}
+
+func newBackend(cfg Config, now func() time.Time) (*Backend, error) {
+	b := &Backend{
+		chips:      map[int32]*chip{},
+		uuids:      map[string]int32{},
+		script:     cfg.HealthScript,
+		start:      now(),
+		now:        now,
+		vdevs:      map[int32][]common.CgoVDevQueryStru{},
+		nextVDevID: firstVDevID,
+	}
+	phyIDs := map[int32]bool{}
+	for _, card := range cfg.Cards {
+		for i, c := range card.Chips {
+			pos := int32(len(b.order))
+			ch := &chip{
+				Chip:     c,
+				cardID:   card.CardID,
+				logicID:  valueOr(c.LogicID, pos),
+				phyID:    valueOr(c.PhyID, pos),
+				deviceID: valueOr(c.DeviceID, int32(i)),
+			}
+			if ch.Name == "" {
+				return nil, fmt.Errorf("chip %d of card %d has no name", i, card.CardID)
+			}
+			if ch.UUID == "" {
+				return nil, fmt.Errorf("chip %d of card %d has no uuid", i, card.CardID)
+			}
+			if _, ok := b.chips[ch.logicID]; ok {
+				return nil, fmt.Errorf("duplicate logic ID %d", ch.logicID)
+			}
+			if phyIDs[ch.phyID] {
+				return nil, fmt.Errorf("duplicate physical ID %d", ch.phyID)
+			}
+			if _, ok := b.uuids[ch.UUID]; ok {
+				return nil, fmt.Errorf("duplicate uuid %s", ch.UUID)
+			}
+			b.chips[ch.logicID] = ch
+			b.order = append(b.order, ch.logicID)
+			b.uuids[ch.UUID] = ch.logicID
+			phyIDs[ch.phyID] = true
+			for _, v := range c.VNPUs {
+				if v.Template == "" {
+					return nil, fmt.Errorf("vNPU of chip %s has no template", ch.UUID)
+				}
+				b.addVDev(ch.logicID, v.Template, v.InUse)
+			}
+		}
+	}
+	if len(b.order) == 0 {
+		return nil, fmt.Errorf("no chips configured")
+	}
+	if err := b.checkScript(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func valueOr(v *int32, def int32) int32 {
+	if v == nil {
+		return def
+	}
+	return *v
+}
+
+// checkScript validates the health script and sorts its steps by time.
+func (b *Backend) checkScript() error {
+	steps := b.script.Steps
+	for i, s := range steps {
+		if _, ok := b.uuids[s.UUID]; !ok {
+			return fmt.Errorf("health script step %d: unknown uuid %q", i, s.UUID)
+		}
+		if s.After.Duration < 0 {
+			return fmt.Errorf("health script step %d: negative after %s", i, s.After.Duration)
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].After.Duration < steps[j].After.Duration })
+	if every := b.script.RepeatEvery; every != nil && len(steps) > 0 {
+		if last := steps[len(steps)-1].After.Duration; every.Duration <= last {
+			return fmt.Errorf("health script repeatEvery %s must be longer than its last step at %s", every.Duration, last)
+		}
+	}
+	return nil
+}
+
+func (b *Backend) chip(logicID int32) (*chip, error) {
+	c, ok := b.chips[logicID]
+	if !ok {
+		return nil, fmt.Errorf("no device with logic ID %d", logicID)
+	}
+	return c, nil
+}
+
+// health returns the state of the chip at the current point of the health
+// script: its configured health with all steps due so far applied.
+func (b *Backend) health(c *chip) health {
+	h := health{health: c.Health, codes: c.ErrorCodes}
+	elapsed := b.now().Sub(b.start)
+	if every := b.script.RepeatEvery; every != nil {
+		elapsed %= every.Duration
+	}
+	for _, s := range b.script.Steps {
+		if s.After.Duration > elapsed {
+			break
+		}
+		if s.UUID == c.UUID {
+			h = health{health: s.Health, codes: s.ErrorCodes}
+		}
+	}
+	return h
+}
+
+// addVDev adds a vNPU to the chip with the given logic ID. The caller holds
+// b.mu or owns b exclusively.
+func (b *Backend) addVDev(logicID int32, template string, inUse bool) uint32 {
+	id := b.nextVDevID
+	b.nextVDevID++
+	used := uint32(0)
+	if inUse {
+		used = 1
+	}
+	b.vdevs[logicID] = append(b.vdevs[logicID], common.CgoVDevQueryStru{
+		VDevID:    id,
+		QueryInfo: common.CgoVDevQueryInfo{Name: template, IsContainerUsed: used},
+	})
+	return id
+}
+
+// GetDeviceList returns the logic IDs of all chips.
+func (b *Backend) GetDeviceList() (int32, []int32, error) {
+	return int32(len(b.order)), append([]int32(nil), b.order...), nil
+}
+
+// GetChipInfo returns the name of the chip with the given logic ID.
+func (b *Backend) GetChipInfo(logicID int32) (*common.ChipInfo, error) {
+	c, err := b.chip(logicID)
+	if err != nil {
+		return nil, err
+	}
+	return &common.ChipInfo{Type: "Ascend", Name: c.Name}, nil
+}
+
+// GetValidChipInfo returns the chip info of the first chip.
+func (b *Backend) GetValidChipInfo() (common.ChipInfo, error) {
+	info, err := b.GetChipInfo(b.order[0])
+	if err != nil {
+		return common.ChipInfo{}, err
+	}
+	return *info, nil
+}
+
+func (b *Backend) GetPhysicIDFromLogicID(logicID int32) (int32, error) {
+	c, err := b.chip(logicID)
+	if err != nil {
+		return 0, err
+	}
+	return c.phyID, nil
+}
+
+func (b *Backend) GetCardIDDeviceID(logicID int32) (int32, int32, error) {
+	c, err := b.chip(logicID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return c.cardID, c.deviceID, nil
+}
+
+func (b *Backend) GetDieID(logicID int32, _ dcmi.DieType) (string, error) {
+	c, err := b.chip(logicID)
+	if err != nil {
+		return "", err
+	}
+	return c.UUID, nil
+}
+
+func (b *Backend) GetPCIeBusInfo(logicID int32) (string, error) {
+	c, err := b.chip(logicID)
+	if err != nil {
+		return "", err
+	}
+	if c.BusID == "" {
+		return "", fmt.Errorf("no PCIe bus ID configured for logic ID %d", logicID)
+	}
+	return c.BusID, nil
+}
+
+func (b *Backend) GetProductType(cardID, deviceID int32) (string, error) {
+	for _, id := range b.order {
+		if c := b.chips[id]; c.cardID == cardID && c.deviceID == deviceID {
+			return c.ProductType, nil
+		}
+	}
+	return "", fmt.Errorf("no device %d on card %d", deviceID, cardID)
+}
+
+func (b *Backend) GetDeviceHealth(logicID int32) (uint32, error) {
+	c, err := b.chip(logicID)
+	if err != nil {
+		return 0, err
+	}
+	return b.health(c).health, nil
+}
+
+func (b *Backend) GetDeviceAllErrorCode(logicID int32) (int32, []int64, error) {
+	c, err := b.chip(logicID)
+	if err != nil {
+		return 0, nil, err
+	}
+	codes := append([]int64(nil), b.health(c).codes...)
+	return int32(len(codes)), codes, nil
+}
+
+// GetDeviceErrorCode returns the first error code of the chip.
+func (b *Backend) GetDeviceErrorCode(logicID int32) (int32, int64, error) {
+	count, codes, err := b.GetDeviceAllErrorCode(logicID)
+	if err != nil || count == 0 {
+		return 0, 0, err
+	}
+	return count, codes[0], nil
+}
+
+func (b *Backend) GetDeviceUtilizationRate(logicID int32, _ common.DeviceType) (uint32, error) {
+	return 0, b.notSimulated(logicID, "utilization")
+}
+
+func (b *Backend) GetDeviceTemperature(logicID int32) (int32, error) {
+	return 0, b.notSimulated(logicID, "temperature")
+}
+
+func (b *Backend) GetDevicePowerInfo(logicID int32) (float32, error) {
+	return 0, b.notSimulated(logicID, "power")
+}
+
+// notSimulated returns the error of a query without simulated data, or the
+// error for an unknown chip.
+func (b *Backend) notSimulated(logicID int32, what string) error {
+	if _, err := b.chip(logicID); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s of logic ID %d: %w", what, logicID, ErrNotSimulated)
+}
+
+func (b *Backend) GetDeviceMemoryInfo(logicID int32) (*common.MemoryInfo, error) {
+	c, err := b.chip(logicID)
+	if err != nil {
+		return nil, err
+	}
+	return &common.MemoryInfo{MemorySize: c.Memory, MemoryAvailable: c.Memory}, nil
+}
+
+func (b *Backend) GetDeviceHbmInfo(logicID int32) (*common.HbmInfo, error) {
+	c, err := b.chip(logicID)
+	if err != nil {
+		return nil, err
+	}
+	return &common.HbmInfo{MemorySize: c.Memory}, nil
+}
+
+// CreateVirtualDevice creates a vNPU of the given template. The requested
+// vNPU ID is ignored; IDs are handed out from 100 on.
+func (b *Backend) CreateVirtualDevice(logicID int32, res common.CgoCreateVDevRes) (common.CgoCreateVDevOut, error) {
+	if _, err := b.chip(logicID); err != nil {
+		return common.CgoCreateVDevOut{}, err
+	}
+	if res.TemplateName == "" {
+		return common.CgoCreateVDevOut{}, fmt.Errorf("no template given for vNPU on logic ID %d", logicID)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return common.CgoCreateVDevOut{VDevID: b.addVDev(logicID, res.TemplateName, false)}, nil
+}
+
+func (b *Backend) GetVirtualDeviceInfo(logicID int32) (common.VirtualDevInfo, error) {
+	if _, err := b.chip(logicID); err != nil {
+		return common.VirtualDevInfo{}, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return common.VirtualDevInfo{VDevInfo: append([]common.CgoVDevQueryStru(nil), b.vdevs[logicID]...)}, nil
+}
+
+func (b *Backend) DestroyVirtualDevice(logicID int32, vDevID uint32) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	vdevs := b.vdevs[logicID]
+	for i, v := range vdevs {
+		if v.VDevID == vDevID {
+			b.vdevs[logicID] = append(vdevs[:i:i], vdevs[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no vNPU %d on device %d", vDevID, logicID)
+}
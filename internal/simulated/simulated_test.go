@@ -0,0 +1,333 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulated
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ascend-common/devmanager/common"
+	"ascend-common/devmanager/dcmi"
+)
+
+const testConfig = `
+cards:
+- cardID: 3
+  chips:
+  - name: 910B3
+    uuid: uuid-a
+    productType: Atlas 800I A2
+    busID: "0000:C1:00.0"
+    memory: 65536
+    vnpus:
+    - template: vir05_1c_16g
+      inUse: true
+  - name: 910B3
+    uuid: uuid-b
+    phyID: 7
+    health: 2
+    errorCodes: [0x80E18402]
+- cardID: 4
+  chips:
+  - name: 310P3
+    uuid: uuid-c
+    logicID: 9
+healthScript:
+  repeatEvery: 2m
+  steps:
+  - after: 60s
+    uuid: uuid-a
+    health: 3
+    errorCodes: [0x80E01801]
+  - after: 30s
+    uuid: uuid-b
+    health: 0
+`
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "simulated.yaml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+// fakeClock is a settable clock for the health script.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestBackend(t *testing.T) (*Backend, *fakeClock) {
+	t.Helper()
+	b, err := Load(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	b.start, b.now = clock.t, clock.now
+	return b, clock
+}
+
+// ============================================================================
+// Load tests
+// ============================================================================
+
+func TestLoad(t *testing.T) {
+	b, _ := newTestBackend(t)
+
+	_, ids, err := b.GetDeviceList()
+	if err != nil {
+		t.Fatalf("GetDeviceList() error = %v", err)
+	}
+	if want := []int32{0, 1, 9}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("logic IDs = %v, want %v", ids, want)
+	}
+
+	tests := []struct {
+		logicID          int32
+		uuid, name       string
+		phyID            int32
+		cardID, deviceID int32
+	}{
+		{logicID: 0, uuid: "uuid-a", name: "910B3", phyID: 0, cardID: 3, deviceID: 0},
+		{logicID: 1, uuid: "uuid-b", name: "910B3", phyID: 7, cardID: 3, deviceID: 1},
+		{logicID: 9, uuid: "uuid-c", name: "310P3", phyID: 2, cardID: 4, deviceID: 0},
+	}
+	for _, tc := range tests {
+		if uuid, _ := b.GetDieID(tc.logicID, dcmi.VDIE); uuid != tc.uuid {
+			t.Errorf("logic ID %d: uuid = %q, want %q", tc.logicID, uuid, tc.uuid)
+		}
+		if info, _ := b.GetChipInfo(tc.logicID); info.Type != "Ascend" || info.Name != tc.name {
+			t.Errorf("logic ID %d: chip info = %+v, want Ascend %s", tc.logicID, info, tc.name)
+		}
+		if phyID, _ := b.GetPhysicIDFromLogicID(tc.logicID); phyID != tc.phyID {
+			t.Errorf("logic ID %d: phyID = %d, want %d", tc.logicID, phyID, tc.phyID)
+		}
+		if cardID, deviceID, _ := b.GetCardIDDeviceID(tc.logicID); cardID != tc.cardID || deviceID != tc.deviceID {
+			t.Errorf("logic ID %d: card/device = %d/%d, want %d/%d", tc.logicID, cardID, deviceID, tc.cardID, tc.deviceID)
+		}
+	}
+
+	if pt, _ := b.GetProductType(3, 0); pt != "Atlas 800I A2" {
+		t.Errorf("product type = %q, want Atlas 800I A2", pt)
+	}
+	if busID, _ := b.GetPCIeBusInfo(0); busID != "0000:C1:00.0" {
+		t.Errorf("bus ID = %q, want 0000:C1:00.0", busID)
+	}
+	if _, err := b.GetPCIeBusInfo(1); err == nil {
+		t.Error("GetPCIeBusInfo() of a chip without bus ID succeeded")
+	}
+	if mem, _ := b.GetDeviceMemoryInfo(0); mem.MemorySize != 65536 {
+		t.Errorf("memory = %d, want 65536", mem.MemorySize)
+	}
+	if _, err := b.GetDieID(5, dcmi.VDIE); err == nil {
+		t.Error("GetDieID() of an unknown logic ID succeeded")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:    "NoChips",
+			config:  "cards: []",
+			wantErr: "no chips",
+		},
+		{
+			name:    "NoUUID",
+			config:  "cards: [{cardID: 0, chips: [{name: 910B3}]}]",
+			wantErr: "no uuid",
+		},
+		{
+			name:    "DuplicateUUID",
+			config:  "cards: [{cardID: 0, chips: [{name: 910B3, uuid: a}, {name: 910B3, uuid: a}]}]",
+			wantErr: "duplicate uuid",
+		},
+		{
+			name:    "DuplicatePhyID",
+			config:  "cards: [{cardID: 0, chips: [{name: 910B3, uuid: a}, {name: 910B3, uuid: b, phyID: 0}]}]",
+			wantErr: "duplicate physical ID",
+		},
+		{
+			name: "ScriptUnknownUUID",
+			config: "cards: [{cardID: 0, chips: [{name: 910B3, uuid: a}]}]\n" +
+				"healthScript: {steps: [{after: 1s, uuid: b, health: 2}]}",
+			wantErr: "unknown uuid",
+		},
+		{
+			name: "RepeatBeforeLastStep",
+			config: "cards: [{cardID: 0, chips: [{name: 910B3, uuid: a}]}]\n" +
+				"healthScript: {repeatEvery: 1m, steps: [{after: 90s, uuid: a, health: 2}]}",
+			wantErr: "repeatEvery",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tc.config))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Load() error = %v, want one containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Health script tests
+// ============================================================================
+
+func TestHealthScript(t *testing.T) {
+	b, clock := newTestBackend(t)
+
+	tests := []struct {
+		name      string
+		elapsed   time.Duration
+		logicID   int32
+		wantCode  uint32
+		wantCodes []int64
+	}{
+		{name: "AInitial", elapsed: 0, logicID: 0, wantCode: 0},
+		{name: "BInitial", elapsed: 0, logicID: 1, wantCode: 2, wantCodes: []int64{0x80E18402}},
+		{name: "BRecovered", elapsed: 30 * time.Second, logicID: 1, wantCode: 0},
+		{name: "ANotYetFaulty", elapsed: 59 * time.Second, logicID: 0, wantCode: 0},
+		{name: "AFaulty", elapsed: 90 * time.Second, logicID: 0, wantCode: 3, wantCodes: []int64{0x80E01801}},
+		{name: "BRestarted", elapsed: 2*time.Minute + 10*time.Second, logicID: 1, wantCode: 2, wantCodes: []int64{0x80E18402}},
+		{name: "ARestarted", elapsed: 2*time.Minute + 10*time.Second, logicID: 0, wantCode: 0},
+		{name: "UnscriptedChip", elapsed: 90 * time.Second, logicID: 9, wantCode: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			clock.t = b.start.Add(tc.elapsed)
+			code, err := b.GetDeviceHealth(tc.logicID)
+			if err != nil || code != tc.wantCode {
+				t.Errorf("GetDeviceHealth() = %d, %v, want %d", code, err, tc.wantCode)
+			}
+			n, codes, err := b.GetDeviceAllErrorCode(tc.logicID)
+			if err != nil || int(n) != len(tc.wantCodes) || len(codes) != len(tc.wantCodes) ||
+				(len(codes) > 0 && !reflect.DeepEqual(codes, tc.wantCodes)) {
+				t.Errorf("GetDeviceAllErrorCode() = %d, %#x, %v, want %#x", n, codes, err, tc.wantCodes)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// vNPU tests
+// ============================================================================
+
+func TestVirtualDevices(t *testing.T) {
+	b, _ := newTestBackend(t)
+
+	info, err := b.GetVirtualDeviceInfo(0)
+	if err != nil {
+		t.Fatalf("GetVirtualDeviceInfo() error = %v", err)
+	}
+	if len(info.VDevInfo) != 1 || info.VDevInfo[0].VDevID != firstVDevID ||
+		info.VDevInfo[0].QueryInfo.Name != "vir05_1c_16g" || info.VDevInfo[0].QueryInfo.IsContainerUsed != 1 {
+		t.Fatalf("configured vNPUs = %+v, want one used vir05_1c_16g with ID %d", info.VDevInfo, firstVDevID)
+	}
+
+	out, err := b.CreateVirtualDevice(1, common.CgoCreateVDevRes{TemplateName: "vir10_3c_32g"})
+	if err != nil {
+		t.Fatalf("CreateVirtualDevice() error = %v", err)
+	}
+	if out.VDevID != firstVDevID+1 {
+		t.Errorf("created vNPU ID = %d, want %d", out.VDevID, firstVDevID+1)
+	}
+	if _, err := b.CreateVirtualDevice(1, common.CgoCreateVDevRes{}); err == nil {
+		t.Error("CreateVirtualDevice() without template succeeded")
+	}
+	if _, err := b.CreateVirtualDevice(5, common.CgoCreateVDevRes{TemplateName: "vir10_3c_32g"}); err == nil {
+		t.Error("CreateVirtualDevice() on an unknown logic ID succeeded")
+	}
+
+	info, _ = b.GetVirtualDeviceInfo(1)
+	if len(info.VDevInfo) != 1 || info.VDevInfo[0].QueryInfo.IsContainerUsed != 0 {
+		t.Fatalf("vNPUs of logic ID 1 = %+v, want the idle created one", info.VDevInfo)
+	}
+
+	if err := b.DestroyVirtualDevice(1, out.VDevID); err != nil {
+		t.Fatalf("DestroyVirtualDevice() error = %v", err)
+	}
+	if err := b.DestroyVirtualDevice(1, out.VDevID); err == nil {
+		t.Error("second DestroyVirtualDevice() succeeded")
+	}
+	if info, _ = b.GetVirtualDeviceInfo(1); len(info.VDevInfo) != 0 {
+		t.Errorf("vNPUs of logic ID 1 after destroy = %+v, want none", info.VDevInfo)
+	}
+}
+
+// ============================================================================
+// Unsimulated methods
+// ============================================================================
+
+func TestUnsimulatedMethods(t *testing.T) {
+	b, _ := newTestBackend(t)
+
+	tests := []struct {
+		name string
+		call func(logicID int32) error
+	}{
+		{"GetDeviceUtilizationRate", func(id int32) error {
+			_, err := b.GetDeviceUtilizationRate(id, common.AICore)
+			return err
+		}},
+		{"GetDeviceTemperature", func(id int32) error {
+			_, err := b.GetDeviceTemperature(id)
+			return err
+		}},
+		{"GetDevicePowerInfo", func(id int32) error {
+			_, err := b.GetDevicePowerInfo(id)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(0); !errors.Is(err, ErrNotSimulated) {
+				t.Errorf("%s(0) error = %v, want ErrNotSimulated", tt.name, err)
+			}
+			if err := tt.call(5); err == nil || errors.Is(err, ErrNotSimulated) {
+				t.Errorf("%s(5) error = %v, want the unknown chip error", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestGetDeviceErrorCode(t *testing.T) {
+	b, _ := newTestBackend(t)
+
+	if count, code, err := b.GetDeviceErrorCode(1); err != nil || count != 1 || code != 0x80E18402 {
+		t.Errorf("GetDeviceErrorCode(1) = %d, %#x, %v, want 1, 0x80E18402, nil", count, code, err)
+	}
+	if count, code, err := b.GetDeviceErrorCode(0); err != nil || count != 0 || code != 0 {
+		t.Errorf("GetDeviceErrorCode(0) = %d, %#x, %v, want no code", count, code, err)
+	}
+	if _, _, err := b.GetDeviceErrorCode(5); err == nil {
+		t.Error("GetDeviceErrorCode() on an unknown logic ID succeeded")
+	}
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// MaxVDeviceCount bounds vDeviceCount. hami-vnpu-core hands out AICore in
+// percent of a device, so more than 100 vNPUs per device cannot each get a
+// share.
+const MaxVDeviceCount = 100
+
+// ValidateConfig checks the device config beyond what decoding checks and
+// returns every problem found, with the path of the offending field under
+// fldPath.
+func ValidateConfig(c *Config, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	configsPath := fldPath.Child("vnpus", "configs")
+	allErrs = append(allErrs, validateFaultSeverities(c.VNPUs.FaultSeverities, fldPath.Child("vnpus", "faultSeverities"))...)
+	if len(c.VNPUs.Configs) == 0 {
+		return append(allErrs, field.Required(configsPath, "at least one chip config is needed"))
+	}
+	chipNames := map[string]int{}
+	commonWords := map[string]int{}
+	for i := range c.VNPUs.Configs {
+		vnpu := &c.VNPUs.Configs[i]
+		idxPath := configsPath.Index(i)
+		allErrs = append(allErrs, validateVNPUConfig(vnpu, idxPath)...)
+		if vnpu.ChipName != "" {
+			if j, ok := chipNames[vnpu.ChipName]; ok {
+				allErrs = append(allErrs, duplicateOf(idxPath.Child("chipName"), vnpu.ChipName, configsPath.Index(j)))
+			} else {
+				chipNames[vnpu.ChipName] = i
+			}
+		}
+		// commonWord names the node annotations of the chip, so two chips
+		// sharing it would overwrite each other's registration.
+		if vnpu.CommonWord != "" {
+			if j, ok := commonWords[vnpu.CommonWord]; ok {
+				allErrs = append(allErrs, duplicateOf(idxPath.Child("commonWord"), vnpu.CommonWord, configsPath.Index(j)))
+			} else {
+				commonWords[vnpu.CommonWord] = i
+			}
+		}
+	}
+	return allErrs
+}
+
+// faultSeverities are the severities an error code can be given.
+var faultSeverities = []string{"None", "Minor", "Major", "Critical"}
+
+func validateFaultSeverities(m map[string]string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, code := range sortedKeys(m) {
+		if _, err := strconv.ParseInt(code, 0, 64); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(code), code, "must be an error code, e.g. 0x80E01801"))
+		}
+		if !slices.Contains(faultSeverities, m[code]) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Key(code), m[code], faultSeverities))
+		}
+	}
+	return allErrs
+}
+
+// duplicateOf reports value at fldPath as a duplicate of the one at first.
+func duplicateOf(fldPath *field.Path, value any, first *field.Path) *field.Error {
+	err := field.Duplicate(fldPath, value)
+	err.Detail = "also at " + first.String()
+	return err
+}
+
+func validateVNPUConfig(c *VNPUConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if c.ChipName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("chipName"), ""))
+	}
+	if c.CommonWord == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("commonWord"), ""))
+	}
+	allErrs = append(allErrs, validateResourceName(c.ResourceName, fldPath.Child("resourceName"))...)
+	allErrs = append(allErrs, validateResourceName(c.ResourceMemoryName, fldPath.Child("resourceMemoryName"))...)
+	if c.ResourceName != "" && c.ResourceMemoryName == c.ResourceName {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("resourceMemoryName"), c.ResourceMemoryName, "must differ from resourceName"))
+	}
+
+	if c.MemoryAllocatable <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("memoryAllocatable"), c.MemoryAllocatable, "must be greater than 0"))
+	}
+	if c.MemoryCapacity <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("memoryCapacity"), c.MemoryCapacity, "must be greater than 0"))
+	} else if c.MemoryAllocatable > c.MemoryCapacity {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("memoryAllocatable"), c.MemoryAllocatable, fmt.Sprintf("must not exceed memoryCapacity (%d)", c.MemoryCapacity)))
+	}
+	if c.AICore < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("aiCore"), c.AICore, "must not be negative"))
+	}
+	if c.AICPU < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("aiCPU"), c.AICPU, "must not be negative"))
+	}
+
+	if c.Profile != nil {
+		allErrs = append(allErrs, validateContainerProfile(c.Profile, fldPath.Child("profile"))...)
+	}
+
+	names := map[string]int{}
+	templatesPath := fldPath.Child("templates")
+	for i, t := range c.Templates {
+		idxPath := templatesPath.Index(i)
+		if t.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if j, ok := names[t.Name]; ok {
+			allErrs = append(allErrs, duplicateOf(idxPath.Child("name"), t.Name, templatesPath.Index(j)))
+		} else {
+			names[t.Name] = i
+		}
+		switch {
+		case t.Memory <= 0:
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("memory"), t.Memory, "must be greater than 0"))
+		case c.MemoryAllocatable > 0 && t.Memory > c.MemoryAllocatable:
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("memory"), t.Memory, fmt.Sprintf("must not exceed memoryAllocatable (%d)", c.MemoryAllocatable)))
+		}
+		switch {
+		case t.AICore < 0:
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("aiCore"), t.AICore, "must not be negative"))
+		case c.AICore > 0 && t.AICore > c.AICore:
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("aiCore"), t.AICore, fmt.Sprintf("must not exceed the chip's aiCore (%d)", c.AICore)))
+		}
+		switch {
+		case t.AICPU < 0:
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("aiCPU"), t.AICPU, "must not be negative"))
+		case c.AICPU > 0 && t.AICPU > c.AICPU:
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("aiCPU"), t.AICPU, fmt.Sprintf("must not exceed the chip's aiCPU (%d)", c.AICPU)))
+		}
+	}
+	return allErrs
+}
+
+// reservedProfileEnv are the env vars Allocate sets itself, which a
+// container profile cannot override.
+var reservedProfileEnv = []string{
+	"ASCEND_VISIBLE_DEVICES",
+	"ASCEND_VNPU_SPECS",
+	"NPU_MEM_QUOTA",
+	"NPU_PRIORITY",
+	"NPU_LOCAL_SHM_PATH",
+}
+
+func validateContainerProfile(p *ContainerProfile, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, m := range p.DriverMounts {
+		idxPath := fldPath.Child("driverMounts").Index(i)
+		allErrs = append(allErrs, validateProfileMount(m, idxPath)...)
+		if len(Placeholders(m.HostPath+m.ContainerPath)) > 0 {
+			allErrs = append(allErrs, field.Forbidden(idxPath, "driver mounts cannot use placeholders, they are shared by all containers"))
+		}
+	}
+	for i, m := range p.Mounts {
+		allErrs = append(allErrs, validateProfileMount(m, fldPath.Child("mounts").Index(i))...)
+	}
+	envPath := fldPath.Child("env")
+	for _, name := range sortedKeys(p.Env) {
+		for _, msg := range validation.IsEnvVarName(name) {
+			allErrs = append(allErrs, field.Invalid(envPath.Key(name), name, msg))
+		}
+		if slices.Contains(reservedProfileEnv, name) {
+			allErrs = append(allErrs, field.Forbidden(envPath.Key(name), "set by the plugin itself"))
+		}
+		allErrs = append(allErrs, validatePlaceholders(p.Env[name], envPath.Key(name))...)
+	}
+	for i, lib := range p.Preload {
+		idxPath := fldPath.Child("preload").Index(i)
+		switch {
+		case !path.IsAbs(lib):
+			allErrs = append(allErrs, field.Invalid(idxPath, lib, "must be an absolute path"))
+		case strings.ContainsAny(lib, " \t\n"):
+			allErrs = append(allErrs, field.Invalid(idxPath, lib, "must not contain whitespace"))
+		}
+		allErrs = append(allErrs, validatePlaceholders(lib, idxPath)...)
+	}
+	return allErrs
+}
+
+func validateProfileMount(m Mount, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, f := range []struct {
+		name, path string
+	}{
+		{"hostPath", m.HostPath},
+		{"containerPath", m.ContainerPath},
+	} {
+		if !path.IsAbs(f.path) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(f.name), f.path, "must be an absolute path"))
+		}
+		allErrs = append(allErrs, validatePlaceholders(f.path, fldPath.Child(f.name))...)
+	}
+	return allErrs
+}
+
+// validatePlaceholders checks that s only uses placeholders Allocate expands.
+func validatePlaceholders(s string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, p := range Placeholders(s) {
+		if !IsKnownPlaceholder(p) {
+			allErrs = append(allErrs, field.Invalid(fldPath, s, fmt.Sprintf("unknown placeholder %s, use %s, %s or %s",
+				p, PlaceholderDeviceID, PlaceholderPodUID, PlaceholderContainerName)))
+		}
+	}
+	return allErrs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateResourceName checks that name is an extended resource name, i.e. a
+// domain-prefixed name outside the kubernetes.io domains.
+func validateResourceName(name string, fldPath *field.Path) field.ErrorList {
+	allErrs := validation.IsDomainPrefixedKey(fldPath, name)
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+	domain := strings.SplitN(name, "/", 2)[0]
+	if domain == "kubernetes.io" || strings.HasSuffix(domain, ".kubernetes.io") ||
+		domain == "k8s.io" || strings.HasSuffix(domain, ".k8s.io") {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, "must not be in the kubernetes.io or k8s.io domain"))
+	}
+	return allErrs
+}
+
+// ValidateNodeConfig checks the node config beyond what decoding checks and
+// returns every problem found, with the path of the offending field under
+// fldPath.
+func ValidateNodeConfig(c *NodeListConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	nodesPath := fldPath.Child("nodes")
+	names := map[string]int{}
+	for i, n := range c.Nodes {
+		idxPath := nodesPath.Index(i)
+		if n.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if j, ok := names[n.Name]; ok {
+			allErrs = append(allErrs, duplicateOf(idxPath.Child("name"), n.Name, nodesPath.Index(j)))
+		} else {
+			names[n.Name] = i
+		}
+		if n.VDeviceCount < 0 || n.VDeviceCount > MaxVDeviceCount {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("vDeviceCount"), n.VDeviceCount, fmt.Sprintf("must be between 0 (chip default) and %d", MaxVDeviceCount)))
+		}
+		allErrs = append(allErrs, validateFilterDevices(n.FilterDevices, idxPath.Child("filterDevices"))...)
+	}
+	return allErrs
+}
+
+func validateFilterDevices(fd FilterDevices, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	uuids := map[string]bool{}
+	for i, uuid := range fd.UUID {
+		idxPath := fldPath.Child("uuid").Index(i)
+		switch {
+		case strings.TrimSpace(uuid) == "":
+			allErrs = append(allErrs, field.Required(idxPath, "an empty UUID matches no device"))
+		case strings.TrimSpace(uuid) != uuid:
+			allErrs = append(allErrs, field.Invalid(idxPath, uuid, "must not have leading or trailing spaces"))
+		case uuids[uuid]:
+			allErrs = append(allErrs, field.Duplicate(idxPath, uuid))
+		}
+		uuids[uuid] = true
+	}
+	indexes := map[int32]bool{}
+	for i, index := range fd.Index {
+		idxPath := fldPath.Child("index").Index(i)
+		switch {
+		case index < 0:
+			allErrs = append(allErrs, field.Invalid(idxPath, index, "must not be negative"))
+		case indexes[index]:
+			allErrs = append(allErrs, field.Duplicate(idxPath, index))
+		}
+		indexes[index] = true
+	}
+	return allErrs
+}
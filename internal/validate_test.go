@@ -0,0 +1,232 @@
+/*
+Copyright 2026 The HAMi Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func validVNPUConfig() VNPUConfig {
+	return VNPUConfig{
+		ChipName:           "910B4",
+		CommonWord:         "Ascend910B4",
+		ResourceName:       "huawei.com/Ascend910B4",
+		ResourceMemoryName: "huawei.com/Ascend910B4-memory",
+		MemoryAllocatable:  32768,
+		MemoryCapacity:     32768,
+		AICore:             20,
+		AICPU:              7,
+		Templates: []Template{
+			{Name: "vir05_1c_8g", Memory: 8192, AICore: 5, AICPU: 1},
+			{Name: "vir10_3c_16g", Memory: 16384, AICore: 10, AICPU: 3},
+		},
+	}
+}
+
+// errorFields returns the paths of errs, so that tests can check where an
+// error was reported without matching its message.
+func errorFields(errs field.ErrorList) []string {
+	fields := make([]string, 0, len(errs))
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	return fields
+}
+
+// ============================================================================
+// Device config
+// ============================================================================
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		want   []string
+	}{
+		{
+			name:   "Valid",
+			mutate: func(c *Config) {},
+			want:   []string{},
+		},
+		{
+			name:   "NoConfigs",
+			mutate: func(c *Config) { c.VNPUs.Configs = nil },
+			want:   []string{"vnpus.configs"},
+		},
+		{
+			name: "TemplateLargerThanAllocatable",
+			mutate: func(c *Config) {
+				c.VNPUs.Configs[0].Templates[1].Memory = 40000
+			},
+			want: []string{"vnpus.configs[0].templates[1].memory"},
+		},
+		{
+			name: "AllocatableLargerThanCapacity",
+			mutate: func(c *Config) {
+				c.VNPUs.Configs[0].MemoryAllocatable = 65536
+			},
+			want: []string{"vnpus.configs[0].memoryAllocatable"},
+		},
+		{
+			name: "TemplateExceedsChipCores",
+			mutate: func(c *Config) {
+				c.VNPUs.Configs[0].Templates[0].AICore = 21
+				c.VNPUs.Configs[0].Templates[0].AICPU = 8
+			},
+			want: []string{"vnpus.configs[0].templates[0].aiCore", "vnpus.configs[0].templates[0].aiCPU"},
+		},
+		{
+			name: "MalformedResourceNames",
+			mutate: func(c *Config) {
+				c.VNPUs.Configs[0].ResourceName = "Ascend910B4"
+				c.VNPUs.Configs[0].ResourceMemoryName = "kubernetes.io/Ascend910B4-memory"
+			},
+			want: []string{"vnpus.configs[0].resourceName", "vnpus.configs[0].resourceMemoryName"},
+		},
+		{
+			name: "DuplicateChipAndTemplate",
+			mutate: func(c *Config) {
+				c.VNPUs.Configs[0].Templates[1].Name = "vir05_1c_8g"
+				dup := validVNPUConfig()
+				dup.CommonWord = "Ascend910B4-2"
+				c.VNPUs.Configs = append(c.VNPUs.Configs, dup)
+			},
+			want: []string{"vnpus.configs[0].templates[1].name", "vnpus.configs[1].chipName"},
+		},
+		{
+			name: "ValidProfile",
+			mutate: func(c *Config) {
+				c.VNPUs.Configs[0].Profile = &ContainerProfile{
+					DriverMounts: []Mount{{HostPath: "/opt/ascend/driver", ContainerPath: "/usr/local/Ascend/driver", ReadOnly: true}},
+					Mounts:       []Mount{{HostPath: "/var/log/npu/{podUID}", ContainerPath: "/var/log/npu"}},
+					Env:          map[string]string{"NPU_GLOBAL_SHM_PATH": "/hami-shared-region/{deviceID}_global_registry"},
+					Preload:      []string{"/hami-vnpu-core/libvnpu.so"},
+				}
+			},
+			want: []string{},
+		},
+		{
+			name: "MalformedProfile",
+			mutate: func(c *Config) {
+				c.VNPUs.Configs[0].Profile = &ContainerProfile{
+					DriverMounts: []Mount{{HostPath: "/opt/ascend/{deviceID}", ContainerPath: "/usr/local/Ascend/driver"}},
+					Mounts:       []Mount{{HostPath: "relative", ContainerPath: "/logs/{podName}"}},
+					Env:          map[string]string{"NPU_MEM_QUOTA": "1", "1BAD": "x"},
+					Preload:      []string{"libvnpu.so"},
+				}
+			},
+			want: []string{
+				"vnpus.configs[0].profile.driverMounts[0]",
+				"vnpus.configs[0].profile.mounts[0].hostPath",
+				"vnpus.configs[0].profile.mounts[0].containerPath",
+				"vnpus.configs[0].profile.env[1BAD]",
+				"vnpus.configs[0].profile.env[NPU_MEM_QUOTA]",
+				"vnpus.configs[0].profile.preload[0]",
+			},
+		},
+		{
+			name: "ValidFaultSeverities",
+			mutate: func(c *Config) {
+				c.VNPUs.FaultSeverities = map[string]string{"0x80E01801": "Critical", "0x80CB8009": "None"}
+			},
+			want: []string{},
+		},
+		{
+			name: "MalformedFaultSeverities",
+			mutate: func(c *Config) {
+				c.VNPUs.FaultSeverities = map[string]string{"80E0180G": "Major", "0x80CB8009": "Fatal"}
+			},
+			want: []string{"vnpus.faultSeverities[0x80CB8009]", "vnpus.faultSeverities[80E0180G]"},
+		},
+		{
+			name: "MissingNames",
+			mutate: func(c *Config) {
+				c.VNPUs.Configs[0].ChipName = ""
+				c.VNPUs.Configs[0].CommonWord = ""
+			},
+			want: []string{"vnpus.configs[0].chipName", "vnpus.configs[0].commonWord"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{VNPUs: VNPUsConfig{Configs: []VNPUConfig{validVNPUConfig()}}}
+			tc.mutate(c)
+			got := errorFields(ValidateConfig(c, nil))
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("ValidateConfig() errors at %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Node config
+// ============================================================================
+
+func TestValidateNodeConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []NodeConfig
+		want  []string
+	}{
+		{
+			name: "Valid",
+			nodes: []NodeConfig{
+				{Name: "node-001", VDeviceCount: 8, FilterDevices: FilterDevices{Index: []int32{0, 1}}},
+				{Name: "node-002", HamiVnpuCore: true},
+			},
+			want: []string{},
+		},
+		{
+			name:  "DuplicateAndMissingNames",
+			nodes: []NodeConfig{{Name: "node-001"}, {Name: "node-001"}, {}},
+			want:  []string{"nodes[1].name", "nodes[2].name"},
+		},
+		{
+			name:  "VDeviceCountOutOfRange",
+			nodes: []NodeConfig{{Name: "node-001", VDeviceCount: -1}, {Name: "node-002", VDeviceCount: MaxVDeviceCount + 1}},
+			want:  []string{"nodes[0].vDeviceCount", "nodes[1].vDeviceCount"},
+		},
+		{
+			name: "MalformedFilterDevices",
+			nodes: []NodeConfig{{Name: "node-001", FilterDevices: FilterDevices{
+				UUID:  []string{"", " uuid1", "uuid2", "uuid2"},
+				Index: []int32{-1, 3, 3},
+			}}},
+			want: []string{
+				"nodes[0].filterDevices.uuid[0]",
+				"nodes[0].filterDevices.uuid[1]",
+				"nodes[0].filterDevices.uuid[3]",
+				"nodes[0].filterDevices.index[0]",
+				"nodes[0].filterDevices.index[2]",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := errorFields(ValidateNodeConfig(&NodeListConfig{Nodes: tc.nodes}, nil))
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("ValidateNodeConfig() errors at %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -39,11 +39,24 @@ type VNPUConfig struct {
 	AICore             int32      `json:"aiCore"`
 	AICPU              int32      `json:"aiCPU"`
 	Templates          []Template `json:"templates"`
+	// Profile overrides the mounts, env and preload entries containers of
+	// this chip get. Unset, DefaultContainerProfile is used.
+	Profile *ContainerProfile `json:"profile,omitempty"`
 }
 
 type VNPUsConfig struct {
-	HamiVnpuCore bool         `json:"hamiVnpuCore,omitempty"`
-	Configs      []VNPUConfig `json:"configs"`
+	HamiVnpuCore bool `json:"hamiVnpuCore,omitempty"`
+	// PreferredAllocation advertises GetPreferredAllocation to kubelet so that
+	// multi-NPU requests are packed into the same HCCS group.
+	PreferredAllocation bool `json:"preferredAllocation,omitempty"`
+	// CreateVNPU makes the plugin create hard-slice vNPUs itself in Allocate
+	// and hand out their IDs, instead of leaving it to the Ascend runtime.
+	CreateVNPU bool `json:"createVNPU,omitempty"`
+	// FaultSeverities sets the severity of driver error codes, keyed by the
+	// code as npu-smi prints it, e.g. "0x80E01801", to None, Minor, Major or
+	// Critical. Codes not listed count as Minor.
+	FaultSeverities map[string]string `json:"faultSeverities,omitempty"`
+	Configs         []VNPUConfig      `json:"configs"`
 }
 
 type Config struct {